@@ -0,0 +1,793 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/ast"
+	"github.com/svkirillov/translator-labs/pkg/difftest"
+	"github.com/svkirillov/translator-labs/pkg/expr"
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/ir"
+	"github.com/svkirillov/translator-labs/pkg/ll1parser"
+	"github.com/svkirillov/translator-labs/pkg/lr1parser"
+	"github.com/svkirillov/translator-labs/pkg/lrparser"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// The default limits of the lr algorithm, which stop it on left recursive
+// grammars within a second.
+const (
+	defaultMaxSteps = 2000
+	defaultMaxDepth = 200
+)
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		for _, c := range commands {
+			if c.name == name {
+				fmt.Fprintf(os.Stderr, "usage: translator %s\n", c.usage)
+			}
+		}
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// newTableFlagSet returns a flag set with the -format flag for the
+// commands that print tables, which sets the format.
+func newTableFlagSet(name string, format *report.Format) *flag.FlagSet {
+	fs := newFlagSet(name)
+	fs.Var(format, "format", "output format: color, text, json, csv, markdown or latex")
+
+	return fs
+}
+
+func write(format report.Format, tables ...report.Table) int {
+	if err := report.Write(os.Stdout, format, tables...); err != nil {
+		return errorf("%v", err)
+	}
+
+	return exitOK
+}
+
+func errorf(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "translator: "+format+"\n", args...)
+	return exitError
+}
+
+// parseArgs parses the flags and returns the grammar read from the first
+// argument and the rest of the arguments.
+func parseArgs(fs *flag.FlagSet, args []string) (*grammar.Grammar, []string, int) {
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, exitError
+	}
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return nil, nil, exitError
+	}
+
+	gr, err := grammar.ReadFile(fs.Arg(0))
+	if err != nil {
+		return nil, nil, errorf("%v", err)
+	}
+
+	return gr, fs.Args()[1:], exitOK
+}
+
+// buildTable builds the table of the algorithm and returns it with its
+// conflicts.
+func buildTable(gr *grammar.Grammar, algo string) (report.Table, report.Table, error) {
+	if algo == "ll1" {
+		ll1p := ll1parser.NewLL1Parser(*gr, "")
+		ll1p.BuildTable()
+		return ll1p.TableReport(), ll1p.ConflictsReport(), nil
+	}
+
+	a, err := lr1parser.ParseAlgorithm(algo)
+	if err != nil {
+		return report.Table{}, report.Table{}, err
+	}
+
+	lr1p := lr1parser.NewLR1Parser(*gr, "")
+	lr1p.SetAlgorithm(a)
+	lr1p.BuildTable()
+
+	return lr1p.TableReport(), lr1p.ConflictsReport(), nil
+}
+
+func runCheck(args []string) int {
+	var format report.Format
+	fs := newTableFlagSet("check", &format)
+	algo := fs.String("algo", "", "also check the table of the algorithm for conflicts")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	code = exitOK
+
+	// the findings are lists of symbols, written in the format with the
+	// conflicts of the table
+	var findings []report.Table
+
+	if unproductive := gr.Unproductive(); len(unproductive) > 0 {
+		findings = append(findings, report.Table{Title: "Unproductive symbols", Rows: [][]string{unproductive}})
+		code = exitFail
+	}
+
+	if unreachable := gr.Unreachable(); len(unreachable) > 0 {
+		findings = append(findings, report.Table{Title: "Unreachable symbols", Rows: [][]string{unreachable}})
+		code = exitFail
+	}
+
+	if recursive := gr.LeftRecursive(); len(recursive) > 0 {
+		findings = append(findings, report.Table{Title: "Left recursive symbols", Rows: [][]string{recursive}})
+		if *algo == "ll1" || *algo == "lr" {
+			code = exitFail
+		}
+	}
+
+	if *algo != "" && *algo != "lr" {
+		_, conflicts, err := buildTable(gr, *algo)
+		if err != nil {
+			return errorf("%v", err)
+		}
+
+		if len(conflicts.Rows) > 0 {
+			findings = append(findings, conflicts)
+			code = exitFail
+		}
+	}
+
+	if c := write(format, findings...); c != exitOK {
+		return c
+	}
+
+	if code == exitOK && (format == report.Color || format == report.Text) {
+		fmt.Println("ok")
+	}
+
+	return code
+}
+
+func runPrint(args []string) int {
+	var format report.Format
+	gr, _, code := parseArgs(newTableFlagSet("print", &format), args)
+	if gr == nil {
+		return code
+	}
+
+	return write(format, gr.Tables()...)
+}
+
+func runFirst(args []string) int {
+	var format report.Format
+	gr, strs, code := parseArgs(newTableFlagSet("first", &format), args)
+	if gr == nil {
+		return code
+	}
+
+	if len(strs) == 0 {
+		for _, nt := range gr.NTokens {
+			strs = append(strs, nt.NTSymbol)
+		}
+	}
+
+	return write(format, gr.FirstTable(strs...))
+}
+
+func runFollow(args []string) int {
+	var format report.Format
+	gr, _, code := parseArgs(newTableFlagSet("follow", &format), args)
+	if gr == nil {
+		return code
+	}
+
+	return write(format, gr.FollowTable())
+}
+
+func runSimplify(args []string) int {
+	var format report.Format
+	fs := newTableFlagSet("simplify", &format)
+	units := fs.Bool("units", true, "remove unit rules")
+	useless := fs.Bool("useless", true, "remove useless symbols")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	simple := gr
+	origin := make(grammar.Origin, len(gr.Rules))
+	for i := range origin {
+		origin[i] = []int{i}
+	}
+
+	if *units {
+		g, o, err := simple.RemoveUnitRules()
+		if err != nil {
+			return errorf("%v", err)
+		}
+		simple, origin = g, origin.Compose(o)
+	}
+
+	if *useless {
+		g, o, err := simple.RemoveUseless()
+		if err != nil {
+			return errorf("%v", err)
+		}
+		simple, origin = g, origin.Compose(o)
+	}
+
+	origins := report.Table{
+		Title:  "Origin",
+		Header: []string{"#", "Original rules"},
+	}
+	for i := range origin {
+		origins.Rows = append(origins.Rows, []string{fmt.Sprintf("%d", i), fmt.Sprintf("%v", origin[i])})
+	}
+
+	states := report.Table{
+		Title: "LR(1) states",
+		Rows:  [][]string{{fmt.Sprintf("%d -> %d", countStates(gr), countStates(simple))}},
+	}
+
+	return write(format, append(simple.Tables(), origins, states)...)
+}
+
+func countStates(gr *grammar.Grammar) int {
+	lr1p := lr1parser.NewLR1Parser(*gr, "")
+	lr1p.BuildTable()
+
+	return len(lr1p.TableReport().Rows)
+}
+
+func runGNF(args []string) int {
+	var format report.Format
+	fs := newTableFlagSet("gnf", &format)
+	verbose := fs.Bool("v", false, "print the intermediate grammars")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	gnf, steps, err := gr.ToGNF()
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	if !*verbose {
+		return write(format, gnf.Tables()...)
+	}
+
+	var tables []report.Table
+	for _, st := range steps {
+		tables = append(tables, report.Table{Title: st.Title, Rows: [][]string{{}}})
+		tables = append(tables, st.Grammar.Tables()...)
+	}
+
+	return write(format, tables...)
+}
+
+func runSample(args []string) int {
+	fs := newFlagSet("sample")
+	n := fs.Int("n", 10, "number of sentences")
+	depth := fs.Int("depth", grammar.DefaultMaxDepth, "depth bound of the derivation trees")
+	seed := fs.Int64("seed", 1, "seed of the random numbers")
+	cover := fs.Bool("cover", false, "go on until every rule is used")
+	invalid := fs.Bool("invalid", false, "print near misses rejected by the parser instead")
+	algo := fs.String("algo", "lr1", "parser rejecting the near misses: lr1, lalr, slr, pager or ll1")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	sentences, err := grammar.Generate(gr, grammar.GenerateOptions{
+		Count:    *n,
+		MaxDepth: *depth,
+		Coverage: *cover,
+		Seed:     *seed,
+	})
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	if *invalid {
+		accepts, err := recognizer(gr, *algo)
+		if err != nil {
+			return errorf("%v", err)
+		}
+		sentences = grammar.NearMisses(gr, sentences, *seed, accepts)
+	}
+
+	for _, s := range sentences {
+		fmt.Println(s)
+	}
+
+	return exitOK
+}
+
+// recognizer returns a function telling if the parser accepts the input.
+// The table of the parser must not have conflicts.
+func recognizer(gr *grammar.Grammar, algo string) (func(string) bool, error) {
+	if algo == "ll1" {
+		ll1p := ll1parser.NewLL1Parser(*gr, "")
+		ll1p.BuildTable()
+		if len(ll1p.Conflicts()) > 0 {
+			return nil, fmt.Errorf("the LL(1) table has conflicts")
+		}
+
+		return func(in string) bool {
+			p := ll1parser.NewLL1Parser(*gr, in)
+			return p.Parse() == nil
+		}, nil
+	}
+
+	a, err := lr1parser.ParseAlgorithm(algo)
+	if err != nil {
+		return nil, err
+	}
+
+	lr1p := lr1parser.NewLR1Parser(*gr, "")
+	lr1p.SetAlgorithm(a)
+	lr1p.BuildTable()
+	if len(lr1p.Conflicts()) > 0 {
+		return nil, fmt.Errorf("the %s table has conflicts", a)
+	}
+
+	return func(in string) bool {
+		lr1p.SetInput(in)
+		return lr1p.Parse() == nil
+	}, nil
+}
+
+func runDifftest(args []string) int {
+	var format report.Format
+	fs := newTableFlagSet("difftest", &format)
+	parsers := fs.String("parsers", "lr,lr1,lalr,slr", "comma separated parsers to compare: lr, lr1, lalr, slr, pager, ll1")
+	n := fs.Int("n", 100, "number of sentences generated")
+	depth := fs.Int("depth", 8, "depth bound of the derivation trees")
+	seed := fs.Int64("seed", 1, "seed of the random numbers")
+	maxSteps := fs.Int("max-steps", defaultMaxSteps, "step limit of the lr algorithm")
+	maxDepth := fs.Int("max-depth", defaultMaxDepth, "stack depth limit of the lr algorithm")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	ps, err := difftest.Parsers(gr, strings.Split(*parsers, ","), *maxSteps, *maxDepth)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	inputs, err := difftest.Inputs(gr, *n, *depth, *seed)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	found := difftest.Run(ps, inputs)
+	if c := write(
+		format,
+		difftest.Report(ps, found),
+		report.Table{Title: "Inputs", Rows: [][]string{{fmt.Sprintf("%d", len(inputs))}}},
+	); c != exitOK {
+		return c
+	}
+
+	if len(found) > 0 {
+		return exitFail
+	}
+
+	return exitOK
+}
+
+func runTable(args []string) int {
+	var format report.Format
+	fs := newTableFlagSet("table", &format)
+	algo := fs.String("algo", "lr1", "table construction algorithm: lr1, lalr, slr, pager or ll1")
+	compact := fs.Bool("compact", false, "also report the size of the compact table")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	var tables []report.Table
+	var conflicts report.Table
+
+	if *algo == "ll1" {
+		if *compact {
+			return errorf("no compact table for %q", *algo)
+		}
+
+		ll1p := ll1parser.NewLL1Parser(*gr, "")
+		ll1p.BuildTable()
+		tables = append(tables, ll1p.TableReport())
+		conflicts = ll1p.ConflictsReport()
+	} else {
+		a, err := lr1parser.ParseAlgorithm(*algo)
+		if err != nil {
+			return errorf("%v", err)
+		}
+
+		// the reports share the table built once
+		lr1p := lr1parser.NewLR1Parser(*gr, "")
+		lr1p.SetAlgorithm(a)
+		lr1p.BuildTable()
+
+		tables = append(tables, lr1p.TableReport())
+		conflicts = lr1p.ConflictsReport()
+		if *compact {
+			tables = append(tables, lr1p.CompressionReport())
+		}
+		if a == lr1parser.Pager {
+			tables = append(tables, lr1p.MergedReport())
+		}
+	}
+
+	if len(conflicts.Rows) > 0 {
+		if c := write(format, append(tables, conflicts)...); c != exitOK {
+			return c
+		}
+		return exitFail
+	}
+
+	return write(format, tables...)
+}
+
+// readInput returns the input from the arguments, the input file or the
+// standard input, without a trailing newline.
+func readInput(args []string, inputFile string, grammarFile string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("too many arguments")
+	}
+
+	if len(args) == 1 {
+		if inputFile != "" {
+			return "", fmt.Errorf("both INPUT and -input-file are given")
+		}
+		return args[0], nil
+	}
+
+	var r io.Reader
+	switch inputFile {
+	case "", "-":
+		if grammarFile == "-" {
+			return "", fmt.Errorf("the grammar and the input cannot both be read from the standard input")
+		}
+		r = os.Stdin
+	default:
+		f, err := os.Open(inputFile)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	in, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(in), "\r\n"), nil
+}
+
+func runParse(args []string) int {
+	var format report.Format
+	fs := newTableFlagSet("parse", &format)
+	algo := fs.String("algo", "lr1", "parsing algorithm: lr1, lalr, slr, pager, ll1 or lr (backtracking)")
+	inputFile := fs.String("input-file", "", "read the input from the file, - for the standard input")
+	maxSteps := fs.Int("max-steps", defaultMaxSteps, "step limit of the lr algorithm, 0 for no limit")
+	maxDepth := fs.Int("max-depth", defaultMaxDepth, "stack depth limit of the lr algorithm, 0 for no limit")
+	timeout := fs.Duration("timeout", 0, "time limit of the lr algorithm, 0 for no limit")
+	show := fs.String("show", "steps", "what to print: steps, tree, dot, qtree, forest, derivation or ast")
+	compact := fs.Bool("compact", false, "parse with the compact table of the lr1, lalr, slr or pager algorithm")
+
+	gr, rest, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	in, err := readInput(rest, *inputFile, fs.Arg(0))
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	var parseErr error
+	var production []int
+	var steps report.Table
+	rightmost := false
+
+	switch *algo {
+	case "lr":
+		lrp := lrparser.NewLRParser(*gr, in)
+		lrp.SetMaxSteps(*maxSteps)
+		lrp.SetMaxDepth(*maxDepth)
+		lrp.SetTimeout(*timeout)
+		lrp.SetTrace(*show == "steps")
+		parseErr = lrp.ParseContext(context.Background())
+		steps = lrp.StepsReport()
+		production = lrp.Production()
+
+		// the steps up to the limit show where the parser got stuck
+		if _, ok := parseErr.(*lrparser.LimitError); ok {
+			if *show == "steps" {
+				if c := write(format, steps); c != exitOK {
+					return c
+				}
+			}
+			return errorf("%v", parseErr)
+		}
+
+	case "ll1":
+		ll1p := ll1parser.NewLL1Parser(*gr, in)
+		ll1p.BuildTable()
+		if len(ll1p.Conflicts()) > 0 {
+			if c := write(format, ll1p.ConflictsReport()); c != exitOK {
+				return c
+			}
+			return exitFail
+		}
+
+		parseErr = ll1p.Parse()
+		steps = ll1p.StepsReport()
+		production = ll1p.Production()
+
+	default:
+		a, err := lr1parser.ParseAlgorithm(*algo)
+		if err != nil {
+			return errorf("%v", err)
+		}
+
+		lr1p := lr1parser.NewLR1Parser(*gr, in)
+		lr1p.SetAlgorithm(a)
+		lr1p.SetCompact(*compact)
+		parseErr = lr1p.Parse()
+		steps = lr1p.StepsReport()
+		production = lr1p.Production()
+		rightmost = true
+	}
+
+	if *show != "steps" {
+		if parseErr != nil {
+			fmt.Fprintf(os.Stderr, "translator: rejected: %v\n", parseErr)
+			return exitFail
+		}
+
+		return showProduction(gr, production, rightmost, *show)
+	}
+
+	if parseErr != nil {
+		if c := write(format, steps, report.Table{Title: "Rejected", Rows: [][]string{{parseErr.Error()}}}); c != exitOK {
+			return c
+		}
+		return exitFail
+	}
+
+	rules := make([]string, len(production))
+	for i := range production {
+		rules[i] = fmt.Sprintf("%d", production[i])
+	}
+
+	return write(format, steps, report.Table{Title: "Production", Rows: [][]string{rules}})
+}
+
+// showProduction prints the parse tree or the derivation of the rules
+// returned by a parser.
+func showProduction(gr *grammar.Grammar, production []int, rightmost bool, show string) int {
+	if show == "derivation" {
+		derive := parsetree.Leftmost
+		if rightmost {
+			derive = parsetree.Rightmost
+		}
+
+		forms, err := derive(gr, production)
+		if err != nil {
+			return errorf("%v", err)
+		}
+
+		fmt.Println(parsetree.Derivation(forms))
+		return exitOK
+	}
+
+	build := parsetree.FromLeftmost
+	if rightmost {
+		build = parsetree.FromRightmost
+	}
+
+	tree, err := build(gr, production)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	switch show {
+	case "tree":
+		fmt.Print(tree)
+	case "dot":
+		if err := tree.WriteDOT(os.Stdout); err != nil {
+			return errorf("%v", err)
+		}
+	case "qtree":
+		fmt.Print(tree.QTree())
+	case "forest":
+		fmt.Print(tree.Forest())
+	case "ast":
+		fmt.Println(ast.FromTree(gr, tree))
+	default:
+		return errorf("unknown -show value %q", show)
+	}
+
+	return exitOK
+}
+
+func runTranslate(args []string) int {
+	fs := newFlagSet("translate")
+	algo := fs.String("algo", "lr1", "table construction algorithm: lr1, lalr, slr or pager")
+	inputFile := fs.String("input-file", "", "read the input from the file, - for the standard input")
+	emit := fs.String("emit", "rpn", "what to emit: rpn, tac or value")
+
+	gr, rest, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	a, err := lr1parser.ParseAlgorithm(*algo)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	in, err := readInput(rest, *inputFile, fs.Arg(0))
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	lr1p := lr1parser.NewLR1Parser(*gr, in)
+	lr1p.SetAlgorithm(a)
+	if err := lr1p.Parse(); err != nil {
+		fmt.Fprintf(os.Stderr, "translator: rejected: %v\n", err)
+		return exitFail
+	}
+
+	e, err := expr.FromReductions(gr, lr1p.Production())
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	switch *emit {
+	case "rpn":
+		fmt.Println(e.RPN())
+	case "tac":
+		for _, line := range e.TAC() {
+			fmt.Println(line)
+		}
+	case "value":
+		v, err := e.Value()
+		if err != nil {
+			return errorf("%v", err)
+		}
+		fmt.Println(v)
+	default:
+		return errorf("unknown -emit value %q", *emit)
+	}
+
+	return exitOK
+}
+
+func runIR(args []string) int {
+	var format report.Format
+	fs := newTableFlagSet("ir", &format)
+	inputFile := fs.String("input-file", "", "read the program from the file, - for the standard input")
+	triples := fs.Bool("triples", false, "print triples instead of quadruples")
+
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	in, err := readInput(fs.Args(), *inputFile, "")
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	code, err := ir.Translate(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "translator: rejected: %v\n", err)
+		return exitFail
+	}
+
+	if *triples {
+		return write(format, code.TriplesReport())
+	}
+
+	return write(format, code.QuadsReport())
+}
+
+func runGenerate(args []string) int {
+	fs := newFlagSet("generate")
+	algo := fs.String("algo", "lr1", "table construction algorithm: lr1, lalr, slr or pager")
+	pkg := fs.String("package", "parser", "name of the generated package")
+	out := fs.String("o", "", "output file, the standard output by default")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	a, err := lr1parser.ParseAlgorithm(*algo)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	lr1p := lr1parser.NewLR1Parser(*gr, "")
+	lr1p.SetAlgorithm(a)
+	lr1p.BuildTable()
+
+	for _, c := range lr1p.Conflicts() {
+		fmt.Fprintf(os.Stderr, "translator: conflict: %s\n", c)
+	}
+
+	if *out == "" {
+		if err := lr1p.WriteGo(os.Stdout, *pkg); err != nil {
+			return errorf("%v", err)
+		}
+		return exitOK
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	err = lr1p.WriteGo(f, *pkg)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	return exitOK
+}
+
+func runAutomaton(args []string) int {
+	fs := newFlagSet("automaton")
+	algo := fs.String("algo", "lr1", "table construction algorithm: lr1, lalr, slr or pager")
+	kernel := fs.Bool("kernel", false, "show only the kernel items of the states")
+	conflicts := fs.Bool("conflicts", false, "highlight the states with conflicts")
+
+	gr, _, code := parseArgs(fs, args)
+	if gr == nil {
+		return code
+	}
+
+	a, err := lr1parser.ParseAlgorithm(*algo)
+	if err != nil {
+		return errorf("%v", err)
+	}
+
+	lr1p := lr1parser.NewLR1Parser(*gr, "")
+	lr1p.SetAlgorithm(a)
+
+	opts := lr1parser.DOTOptions{
+		KernelOnly:         *kernel,
+		HighlightConflicts: *conflicts,
+	}
+	if err := lr1p.WriteDOT(os.Stdout, opts); err != nil {
+		return errorf("%v", err)
+	}
+
+	return exitOK
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Exit codes
+const (
+	exitOK = iota
+	exitFail
+	exitError
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	// set in init since the commands refer to the list in their usage
+	commands = []command{
+		{"check", "check [-algo=lr1|lalr|slr|pager|ll1] GRAMMAR", runCheck},
+		{"print", "print GRAMMAR", runPrint},
+		{"first", "first GRAMMAR [SYMBOLS...]", runFirst},
+		{"follow", "follow GRAMMAR", runFollow},
+		{"simplify", "simplify [-units=false] [-useless=false] GRAMMAR", runSimplify},
+		{"gnf", "gnf [-v] GRAMMAR", runGNF},
+		{"sample", "sample [-n N] [-depth N] [-seed N] [-cover] [-invalid [-algo=lr1|lalr|slr|pager|ll1]] GRAMMAR", runSample},
+		{"table", "table [-algo=lr1|lalr|slr|pager|ll1] [-compact] GRAMMAR", runTable},
+		{"parse", "parse [-algo=lr1|lalr|slr|pager|ll1|lr] [-compact] [-show=steps|tree|dot|qtree|forest|derivation|ast] [-input-file FILE] GRAMMAR [INPUT]", runParse},
+		{"translate", "translate [-algo=lr1|lalr|slr|pager] [-emit=rpn|tac|value] [-input-file FILE] GRAMMAR [INPUT]", runTranslate},
+		{"ir", "ir [-triples] [-input-file FILE] [PROGRAM]", runIR},
+		{"difftest", "difftest [-parsers=lr,lr1,lalr,slr,ll1] [-n N] [-depth N] [-seed N] GRAMMAR", runDifftest},
+		{"automaton", "automaton [-algo=lr1|lalr|slr|pager] [-kernel] [-conflicts] GRAMMAR", runAutomaton},
+		{"generate", "generate [-algo=lr1|lalr|slr|pager] [-package NAME] [-o FILE] GRAMMAR", runGenerate},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: translator COMMAND [flags] GRAMMAR [INPUT]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "GRAMMAR is a grammar file, or - for the standard input. The commands")
+	fmt.Fprintln(os.Stderr, "printing tables take -format=color|text|json|csv|markdown|latex. Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Exit status is 0 on success, 1 if the grammar check fails, the table")
+	fmt.Fprintln(os.Stderr, "has conflicts or the input is rejected, and 2 on other errors.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitError)
+	}
+
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			os.Exit(c.run(os.Args[2:]))
+		}
+	}
+
+	if os.Args[1] != "help" && os.Args[1] != "-h" && os.Args[1] != "--help" {
+		fmt.Fprintf(os.Stderr, "translator: unknown command %q\n", os.Args[1])
+	}
+	usage()
+	os.Exit(exitError)
+}
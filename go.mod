@@ -1,5 +1,7 @@
 module github.com/svkirillov/translator-labs
 
-go 1.14
+go 1.18
 
 require github.com/olekukonko/tablewriter v0.0.4
+
+require github.com/mattn/go-runewidth v0.0.7 // indirect
@@ -0,0 +1,200 @@
+// Package goldentest runs tests on a corpus of grammars and compares their
+// outputs with golden files. It is for the tests only, which get its
+// -update flag.
+//
+// Each case of the corpus is a directory with a grammar.txt, an optional
+// inputs.txt with an input on each line, ε for the empty one, and the
+// golden files NAME.golden with the expected outputs of the tests. Running
+// the tests with -update writes the golden files instead:
+//
+//	go test ./pkg/lr1parser -run Golden -update
+package goldentest
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// Dir is the corpus of the repository, relative to the packages in pkg/.
+const Dir = "../../testdata"
+
+var update = flag.Bool("update", false, "write the golden files")
+
+// Case is a grammar of the corpus with its inputs.
+type Case struct {
+	Name    string
+	Dir     string
+	Grammar *grammar.Grammar
+	Inputs  []string
+}
+
+// Cases returns the cases of the corpus in dir, sorted by name.
+func Cases(dir string) ([]*Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []*Case
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		c := &Case{Name: e.Name(), Dir: filepath.Join(dir, e.Name())}
+
+		c.Grammar, err = grammar.ReadFile(filepath.Join(c.Dir, "grammar.txt"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.Name, err)
+		}
+
+		c.Inputs, err = readInputs(filepath.Join(c.Dir, "inputs.txt"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.Name, err)
+		}
+
+		cases = append(cases, c)
+	}
+
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+
+	return cases, nil
+}
+
+func readInputs(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var inputs []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		in := strings.TrimSpace(sc.Text())
+		switch in {
+		case "":
+			continue
+		case grammar.Epsilon:
+			in = ""
+		}
+		inputs = append(inputs, in)
+	}
+
+	return inputs, sc.Err()
+}
+
+// Run calls test for each case of the corpus in dir and compares each
+// output it returns with the golden file of its name in the case
+// directory.
+func Run(t *testing.T, dir string, test func(t *testing.T, c *Case) map[string]string) {
+	cases, err := Cases(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			for name, got := range test(t, c) {
+				Check(t, filepath.Join(c.Dir, name+".golden"), got)
+			}
+		})
+	}
+}
+
+// Check compares got with the golden file, or writes it with -update.
+func Check(t *testing.T, file string, got string) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(file, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%v; run the tests with -update to create it", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("%s differs from the output:\n%s", file, diff(string(want), got))
+	}
+}
+
+// diff returns the lines of want and got from the first one that differs.
+func diff(want, got string) string {
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+
+	i := 0
+	for i < len(wl) && i < len(gl) && wl[i] == gl[i] {
+		i++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "line %d:\n", i+1)
+	for j := i; j < len(wl) && j < i+5; j++ {
+		fmt.Fprintf(&b, "- %s\n", wl[j])
+	}
+	for j := i; j < len(gl) && j < i+5; j++ {
+		fmt.Fprintf(&b, "+ %s\n", gl[j])
+	}
+
+	return b.String()
+}
+
+// Text returns the tables in the text format, without the trailing spaces
+// of the lines.
+func Text(tables ...report.Table) string {
+	var b bytes.Buffer
+	report.Write(&b, report.Text, tables...)
+
+	lines := strings.Split(b.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Results returns a table of the result of parsing each input: accepted
+// with the rules used, or the error of the parser.
+func Results(inputs []string, parse func(in string) ([]int, error)) report.Table {
+	table := report.Table{
+		Title:  "Results",
+		Header: []string{"Input", "Result", "Rules"},
+	}
+
+	for _, in := range inputs {
+		shown := in
+		if in == "" {
+			shown = grammar.Epsilon
+		}
+
+		rules, err := parse(in)
+		if err != nil {
+			table.Rows = append(table.Rows, []string{shown, err.Error(), ""})
+			continue
+		}
+
+		table.Rows = append(table.Rows, []string{shown, "accepted", strings.Trim(fmt.Sprint(rules), "[]")})
+	}
+
+	return table
+}
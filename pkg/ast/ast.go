@@ -0,0 +1,149 @@
+// Package ast builds abstract syntax trees from the annotations of grammar
+// rules.
+//
+// A rule with an annotation builds a node of the annotation's kind with
+// the nodes of the symbols it refers to as fields. A rule without one
+// passes on the node of its only nonterminal, as E -> T and F -> (E) do,
+// or of its only symbol. Any other rule builds a node named after its left
+// side with all its symbols as fields.
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+)
+
+// Node is a node of an AST. Tokens have the terminal and no kind.
+type Node struct {
+	Kind   string
+	Token  string
+	Fields []Field
+}
+
+// Field is a named child of a node. Fields without a name in the
+// annotation are named after the position of their symbol, $1 and so on.
+type Field struct {
+	Name string
+	Node *Node
+}
+
+// Field returns the node of the field with the name, nil if there is none.
+func (n *Node) Field(name string) *Node {
+	for _, f := range n.Fields {
+		if f.Name == name {
+			return f.Node
+		}
+	}
+
+	return nil
+}
+
+// String returns the node as Kind(field, ...), with tokens as they are and
+// the names of the named fields.
+func (n *Node) String() string {
+	if n == nil {
+		return "nil"
+	}
+
+	if n.Kind == "" {
+		return n.Token
+	}
+
+	fields := make([]string, len(n.Fields))
+	for i, f := range n.Fields {
+		fields[i] = f.Node.String()
+		if !strings.HasPrefix(f.Name, "$") {
+			fields[i] = f.Name + ": " + fields[i]
+		}
+	}
+
+	return fmt.Sprintf("%s(%s)", n.Kind, strings.Join(fields, ", "))
+}
+
+// build returns the node of the rule given the nodes of its right side.
+func build(gr *grammar.Grammar, rule grammar.Rule, args []*Node) *Node {
+	if a := rule.Annotation; a != nil {
+		n := &Node{Kind: a.Kind}
+		for _, f := range a.Fields {
+			name := f.Name
+			if name == "" {
+				name = fmt.Sprintf("$%d", f.Pos)
+			}
+			n.Fields = append(n.Fields, Field{Name: name, Node: args[f.Pos-1]})
+		}
+
+		return n
+	}
+
+	if len(args) == 1 {
+		return args[0]
+	}
+
+	inner := -1
+	for i := range rule.RSymbol {
+		if gr.TokenType(rule.RSymbol[i:i+1]) == grammar.NTerm {
+			if inner >= 0 {
+				inner = -1
+				break
+			}
+			inner = i
+		}
+	}
+	if inner >= 0 {
+		return args[inner]
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	n := &Node{Kind: rule.LSymbol}
+	for i := range args {
+		n.Fields = append(n.Fields, Field{Name: fmt.Sprintf("$%d", i+1), Node: args[i]})
+	}
+
+	return n
+}
+
+// FromTree builds the AST of a parse tree.
+func FromTree(gr *grammar.Grammar, n *parsetree.Node) *Node {
+	if n.Rule < 0 {
+		return &Node{Token: n.Symbol}
+	}
+
+	rule := gr.Rules[n.Rule]
+
+	var args []*Node
+	if rule.RSymbol != "" {
+		for _, c := range n.Children {
+			args = append(args, FromTree(gr, c))
+		}
+	}
+
+	return build(gr, rule, args)
+}
+
+// SetActions sets the semantic actions of the rules to build the AST, so
+// that LR1Parser.Result returns it.
+func SetActions(gr *grammar.Grammar) {
+	for i := range gr.Rules {
+		rule := gr.Rules[i]
+
+		gr.Rules[i].Action = func(args []grammar.Value) grammar.Value {
+			nodes := make([]*Node, len(args))
+			for j := range args {
+				switch v := args[j].(type) {
+				case *Node:
+					nodes[j] = v
+				case string:
+					nodes[j] = &Node{Token: v}
+				}
+			}
+
+			return build(gr, rule, nodes)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/lr1parser"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+)
+
+const annotated = `
+S -> E
+E -> E+T => Add($1, $3) | T
+T -> T*F => Mul(left: $1, right: $3) | F
+F -> (E) | a => Var($1) | b => Var($1)
+`
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		input string
+		ast   string
+	}{
+		{"a", "Var(a)"},
+		{"((b))", "Var(b)"},
+		{"a+b*a", "Add(Var(a), Mul(left: Var(b), right: Var(a)))"},
+		{"(a+b)*a", "Mul(left: Add(Var(a), Var(b)), right: Var(a))"},
+	}
+
+	for _, tt := range tests {
+		gr, err := grammar.Read(strings.NewReader(annotated))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lr1p := lr1parser.NewLR1Parser(*gr, tt.input)
+		if err := lr1p.Parse(); err != nil {
+			t.Fatalf("%q: %v", tt.input, err)
+		}
+
+		tree, err := parsetree.FromRightmost(gr, lr1p.Production())
+		if err != nil {
+			t.Fatalf("%q: %v", tt.input, err)
+		}
+
+		if s := FromTree(gr, tree).String(); s != tt.ast {
+			t.Errorf("%q: FromTree %s, want %s", tt.input, s, tt.ast)
+		}
+
+		SetActions(gr)
+		lr1p = lr1parser.NewLR1Parser(*gr, tt.input)
+		if err := lr1p.Parse(); err != nil {
+			t.Fatalf("%q: %v", tt.input, err)
+		}
+
+		if n, ok := lr1p.Result().(*Node); !ok || n.String() != tt.ast {
+			t.Errorf("%q: parser built %v, want %s", tt.input, lr1p.Result(), tt.ast)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	n := &Node{Kind: "Add", Fields: []Field{
+		{Name: "$1", Node: &Node{Kind: "Var", Fields: []Field{{Name: "$1", Node: &Node{Token: "a"}}}}},
+		{Name: "$3", Node: &Node{Kind: "Mul", Fields: []Field{
+			{Name: "left", Node: &Node{Kind: "Var", Fields: []Field{{Name: "$1", Node: &Node{Token: "b"}}}}},
+			{Name: "right", Node: &Node{Kind: "Var", Fields: []Field{{Name: "$1", Node: &Node{Token: "a"}}}}},
+		}}},
+	}}
+
+	var kinds []string
+	Inspect(n, func(n *Node) bool {
+		if n == nil {
+			return false
+		}
+
+		if n.Kind == "Var" {
+			kinds = append(kinds, n.Field("$1").Token)
+			return false
+		}
+
+		kinds = append(kinds, n.Kind)
+		return true
+	})
+
+	if s := strings.Join(kinds, " "); s != "Add a Mul b a" {
+		t.Errorf("visited %s, want Add a Mul b a", s)
+	}
+}
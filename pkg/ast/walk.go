@@ -0,0 +1,44 @@
+package ast
+
+// Visitor's Visit method is called for each node by Walk. If the visitor w
+// it returns is not nil, Walk visits the fields of the node with w and then
+// calls w.Visit(nil).
+type Visitor interface {
+	Visit(n *Node) (w Visitor)
+}
+
+// Walk traverses the AST in depth-first order, as ast.Walk of the Go
+// standard library does.
+func Walk(v Visitor, n *Node) {
+	if n == nil {
+		return
+	}
+
+	if v = v.Visit(n); v == nil {
+		return
+	}
+
+	for _, f := range n.Fields {
+		if f.Node != nil {
+			Walk(v, f.Node)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(*Node) bool
+
+func (f inspector) Visit(n *Node) Visitor {
+	if f(n) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the AST in depth-first order, calling f for each node
+// and then f(nil) after its fields. The fields are skipped if f returns
+// false.
+func Inspect(n *Node, f func(*Node) bool) {
+	Walk(inspector(f), n)
+}
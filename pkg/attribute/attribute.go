@@ -0,0 +1,267 @@
+// Package attribute evaluates the attribute equations of a grammar over a
+// parse tree.
+package attribute
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+)
+
+// Instance is an attribute of a node of a parse tree.
+type Instance struct {
+	Node *parsetree.Node
+	Name string
+}
+
+func (in Instance) String() string {
+	return in.Node.Symbol + "." + in.Name
+}
+
+// Values are the attribute values of the nodes of a parse tree.
+type Values map[*parsetree.Node]map[string]grammar.Value
+
+// Get returns the value of the attribute of the node.
+func (v Values) Get(n *parsetree.Node, name string) grammar.Value {
+	return v[n][name]
+}
+
+// CycleError is returned for attributes that depend on themselves.
+type CycleError struct {
+	Cycle []Instance
+}
+
+func (e *CycleError) Error() string {
+	names := make([]string, len(e.Cycle))
+	for i := range e.Cycle {
+		names[i] = e.Cycle[i].String()
+	}
+
+	return "circular attribute dependency: " + strings.Join(names, " -> ")
+}
+
+// definition is how the value of an instance is obtained: by an equation
+// applied to other instances, or given.
+type definition struct {
+	equation *grammar.Equation
+	args     []int
+	value    grammar.Value
+}
+
+// Graph is the dependency graph of the attribute instances of a parse tree.
+type Graph struct {
+	instances []Instance
+	index     map[Instance]int
+	defs      []*definition
+}
+
+// NewGraph returns the dependency graph of the attributes of the tree. The
+// terminals have TextAttr and the root has the inherited attributes given.
+func NewGraph(gr *grammar.Grammar, tree *parsetree.Node, inherited map[string]grammar.Value) (*Graph, error) {
+	g := &Graph{index: make(map[Instance]int)}
+
+	for name, v := range inherited {
+		g.define(Instance{tree, name}, &definition{value: v})
+	}
+
+	if err := g.addNode(gr, tree); err != nil {
+		return nil, err
+	}
+
+	for i := range g.defs {
+		if g.defs[i] == nil {
+			return nil, fmt.Errorf("attribute %s has no equation", g.instances[i])
+		}
+	}
+
+	return g, nil
+}
+
+// id returns the number of the instance, adding it if it is new.
+func (g *Graph) id(in Instance) int {
+	if i, ok := g.index[in]; ok {
+		return i
+	}
+
+	g.index[in] = len(g.instances)
+	g.instances = append(g.instances, in)
+	g.defs = append(g.defs, nil)
+
+	return len(g.instances) - 1
+}
+
+func (g *Graph) define(in Instance, def *definition) error {
+	i := g.id(in)
+	if g.defs[i] != nil {
+		return fmt.Errorf("attribute %s has more than one equation", in)
+	}
+	g.defs[i] = def
+
+	return nil
+}
+
+func (g *Graph) addNode(gr *grammar.Grammar, n *parsetree.Node) error {
+	if n.Rule < 0 {
+		if n.Symbol != grammar.Epsilon {
+			return g.define(Instance{n, grammar.TextAttr}, &definition{value: n.Symbol})
+		}
+		return nil
+	}
+
+	rule := gr.Rules[n.Rule]
+	node := func(pos int) *parsetree.Node {
+		if pos == 0 {
+			return n
+		}
+		return n.Children[pos-1]
+	}
+
+	for i := range rule.Equations {
+		eq := &rule.Equations[i]
+
+		def := &definition{equation: eq}
+		for _, a := range eq.Args {
+			def.args = append(def.args, g.id(Instance{node(a.Pos), a.Name}))
+		}
+
+		if err := g.define(Instance{node(eq.Target.Pos), eq.Target.Name}, def); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range n.Children {
+		if err := g.addNode(gr, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Dependencies returns the instances the instance depends on.
+func (g *Graph) Dependencies(in Instance) []Instance {
+	i, ok := g.index[in]
+	if !ok {
+		return nil
+	}
+
+	var deps []Instance
+	for _, j := range g.defs[i].args {
+		deps = append(deps, g.instances[j])
+	}
+
+	return deps
+}
+
+// Order returns the instances in an order where each one comes after the
+// ones it depends on, or a CycleError.
+func (g *Graph) Order() ([]Instance, error) {
+	waiting := make([]int, len(g.instances))
+	users := make([][]int, len(g.instances))
+	var ready []int
+
+	for i := range g.defs {
+		waiting[i] = len(g.defs[i].args)
+		for _, j := range g.defs[i].args {
+			users[j] = append(users[j], i)
+		}
+
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	var order []Instance
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		order = append(order, g.instances[i])
+
+		for _, u := range users[i] {
+			waiting[u]--
+			if waiting[u] == 0 {
+				ready = append(ready, u)
+			}
+		}
+	}
+
+	if len(order) < len(g.instances) {
+		return nil, &CycleError{Cycle: g.cycle(waiting)}
+	}
+
+	return order, nil
+}
+
+// cycle follows unevaluated dependencies from an unevaluated instance until
+// an instance repeats.
+func (g *Graph) cycle(waiting []int) []Instance {
+	i := 0
+	for waiting[i] == 0 {
+		i++
+	}
+
+	seen := make(map[int]int)
+	var path []int
+	for {
+		if start, ok := seen[i]; ok {
+			var cycle []Instance
+			for _, j := range append(path[start:], i) {
+				cycle = append(cycle, g.instances[j])
+			}
+			return cycle
+		}
+
+		seen[i] = len(path)
+		path = append(path, i)
+
+		for _, j := range g.defs[i].args {
+			if waiting[j] > 0 {
+				i = j
+				break
+			}
+		}
+	}
+}
+
+// Evaluate computes the attributes of the tree in the order of their
+// dependencies.
+func (g *Graph) Evaluate() (Values, error) {
+	order, err := g.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(Values)
+	for _, in := range order {
+		def := g.defs[g.index[in]]
+
+		v := def.value
+		if def.equation != nil {
+			args := make([]grammar.Value, len(def.args))
+			for i, j := range def.args {
+				args[i] = values.Get(g.instances[j].Node, g.instances[j].Name)
+			}
+			v = def.equation.Func(args)
+		}
+
+		if values[in.Node] == nil {
+			values[in.Node] = make(map[string]grammar.Value)
+		}
+		values[in.Node][in.Name] = v
+	}
+
+	return values, nil
+}
+
+// Evaluate computes the attributes of the tree. The root has the inherited
+// attributes given.
+func Evaluate(gr *grammar.Grammar, tree *parsetree.Node, inherited map[string]grammar.Value) (Values, error) {
+	g, err := NewGraph(gr, tree, inherited)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.Evaluate()
+}
@@ -0,0 +1,152 @@
+package attribute
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/ll1parser"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+)
+
+func copyArg(args []grammar.Value) grammar.Value {
+	return args[0]
+}
+
+func digit(args []grammar.Value) grammar.Value {
+	return int(args[0].(string)[0] - '0')
+}
+
+// differences is the grammar of differences of digits without left
+// recursion, which is left associative through the inherited attribute i.
+var differences = grammar.GrammarSettings{
+	Root:      "E",
+	TSymbols:  []string{"-", "1", "2", "3"},
+	NTSymbols: []string{"E", "R", "T"},
+	Rules: []grammar.Rule{
+		{LSymbol: "E", RSymbol: "TR", Equations: []grammar.Equation{
+			{Target: grammar.Attr{Pos: 2, Name: "i"}, Args: []grammar.Attr{{Pos: 1, Name: "v"}}, Func: copyArg},
+			{Target: grammar.Attr{Pos: 0, Name: "v"}, Args: []grammar.Attr{{Pos: 2, Name: "s"}}, Func: copyArg},
+		}},
+		{LSymbol: "R", RSymbol: "-TR", Equations: []grammar.Equation{
+			{
+				Target: grammar.Attr{Pos: 3, Name: "i"},
+				Args:   []grammar.Attr{{Pos: 0, Name: "i"}, {Pos: 2, Name: "v"}},
+				Func: func(args []grammar.Value) grammar.Value {
+					return args[0].(int) - args[1].(int)
+				},
+			},
+			{Target: grammar.Attr{Pos: 0, Name: "s"}, Args: []grammar.Attr{{Pos: 3, Name: "s"}}, Func: copyArg},
+		}},
+		{LSymbol: "R", RSymbol: "", Equations: []grammar.Equation{
+			{Target: grammar.Attr{Pos: 0, Name: "s"}, Args: []grammar.Attr{{Pos: 0, Name: "i"}}, Func: copyArg},
+		}},
+		{LSymbol: "T", RSymbol: "1", Equations: []grammar.Equation{
+			{Target: grammar.Attr{Pos: 0, Name: "v"}, Args: []grammar.Attr{{Pos: 1, Name: grammar.TextAttr}}, Func: digit},
+		}},
+		{LSymbol: "T", RSymbol: "2", Equations: []grammar.Equation{
+			{Target: grammar.Attr{Pos: 0, Name: "v"}, Args: []grammar.Attr{{Pos: 1, Name: grammar.TextAttr}}, Func: digit},
+		}},
+		{LSymbol: "T", RSymbol: "3", Equations: []grammar.Equation{
+			{Target: grammar.Attr{Pos: 0, Name: "v"}, Args: []grammar.Attr{{Pos: 1, Name: grammar.TextAttr}}, Func: digit},
+		}},
+	},
+}
+
+var differenceTests = []struct {
+	input string
+	value int
+}{
+	{"3", 3},
+	{"3-1", 2},
+	{"3-1-1", 1},
+	{"1-2-3", -4},
+}
+
+func TestEvaluate(t *testing.T) {
+	gr, err := grammar.New(differences)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range differenceTests {
+		ll1p := ll1parser.NewLL1Parser(*gr, tt.input)
+		if err := ll1p.Parse(); err != nil {
+			t.Fatalf("%q: %v", tt.input, err)
+		}
+
+		tree, err := parsetree.FromLeftmost(gr, ll1p.Production())
+		if err != nil {
+			t.Fatalf("%q: %v", tt.input, err)
+		}
+
+		values, err := Evaluate(gr, tree, nil)
+		if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+
+		if v := values.Get(tree, "v"); v != tt.value {
+			t.Errorf("%q = %v, want %d", tt.input, v, tt.value)
+		}
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	gr, err := grammar.New(differences)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range differenceTests {
+		ll1p := ll1parser.NewLL1Parser(*gr, tt.input)
+
+		attrs, err := ll1p.ParseAttributes(nil)
+		if err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+
+		if v := attrs["v"]; v != tt.value {
+			t.Errorf("%q = %v, want %d", tt.input, v, tt.value)
+		}
+	}
+}
+
+func TestCycle(t *testing.T) {
+	gr, err := grammar.New(grammar.GrammarSettings{
+		Root:      "S",
+		TSymbols:  []string{"a"},
+		NTSymbols: []string{"S", "A"},
+		Rules: []grammar.Rule{
+			{LSymbol: "S", RSymbol: "A", Equations: []grammar.Equation{
+				{Target: grammar.Attr{Pos: 1, Name: "i"}, Args: []grammar.Attr{{Pos: 1, Name: "s"}}, Func: copyArg},
+			}},
+			{LSymbol: "A", RSymbol: "a", Equations: []grammar.Equation{
+				{Target: grammar.Attr{Pos: 0, Name: "s"}, Args: []grammar.Attr{{Pos: 0, Name: "i"}}, Func: copyArg},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gr.CheckLAttributed(); err == nil {
+		t.Error("CheckLAttributed accepts A.i depending on A.s")
+	}
+
+	tree, err := parsetree.FromLeftmost(gr, []int{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Evaluate(gr, tree, nil)
+
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Evaluate returned %v, want a CycleError", err)
+	}
+	if len(cycle.Cycle) != 3 || cycle.Cycle[0] != cycle.Cycle[2] {
+		t.Errorf("cycle %v, want A.i -> A.s -> A.i", cycle.Cycle)
+	}
+}
@@ -0,0 +1,246 @@
+// Package difftest runs the parsers of a grammar on the same inputs and
+// reports the inputs where they disagree.
+package difftest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/ll1parser"
+	"github.com/svkirillov/translator-labs/pkg/lr1parser"
+	"github.com/svkirillov/translator-labs/pkg/lrparser"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// Result is the outcome of parsing an input. Undecided results, such as a
+// parser stopped by a limit, take no part in the comparison.
+type Result struct {
+	Accepted  bool
+	Undecided bool
+	Tree      string // the parse tree of an accepted input
+	Err       error
+}
+
+func (r Result) String() string {
+	switch {
+	case r.Undecided:
+		return fmt.Sprintf("undecided: %v", r.Err)
+	case r.Accepted:
+		return "accepted"
+	default:
+		return "rejected"
+	}
+}
+
+// Parser is a parser under test.
+type Parser struct {
+	Name  string
+	Parse func(in string) Result
+}
+
+// treeResult returns the result of a parser given its production.
+func treeResult(gr *grammar.Grammar, production []int, rightmost bool) Result {
+	build := parsetree.FromLeftmost
+	if rightmost {
+		build = parsetree.FromRightmost
+	}
+
+	tree, err := build(gr, production)
+	if err != nil {
+		return Result{Accepted: true, Err: err}
+	}
+
+	return Result{Accepted: true, Tree: tree.String()}
+}
+
+// Parsers returns the parsers of the grammar with the names: lr, lr1, lalr,
+// slr, pager and ll1. It fails for the table parsers with conflicts in their
+// tables, since their results depend on how the conflicts are resolved.
+// The lr parser is undecided when it hits the step or stack depth limit,
+// which left recursive grammars make it do.
+func Parsers(gr *grammar.Grammar, names []string, maxSteps int, maxDepth int) ([]Parser, error) {
+	var parsers []Parser
+	for _, name := range names {
+		switch name {
+		case "lr":
+			parsers = append(parsers, Parser{Name: name, Parse: func(in string) Result {
+				lrp := lrparser.NewLRParser(*gr, in)
+				lrp.SetMaxSteps(maxSteps)
+				lrp.SetMaxDepth(maxDepth)
+
+				err := lrp.Parse()
+				if _, ok := err.(*lrparser.LimitError); ok {
+					return Result{Undecided: true, Err: err}
+				}
+				if err != nil {
+					return Result{Err: err}
+				}
+
+				return treeResult(gr, lrp.Production(), false)
+			}})
+
+		case "ll1":
+			ll1p := ll1parser.NewLL1Parser(*gr, "")
+			ll1p.BuildTable()
+			if len(ll1p.Conflicts()) > 0 {
+				return nil, fmt.Errorf("the LL(1) table has conflicts")
+			}
+
+			parsers = append(parsers, Parser{Name: name, Parse: func(in string) Result {
+				ll1p := ll1parser.NewLL1Parser(*gr, in)
+				if err := ll1p.Parse(); err != nil {
+					return Result{Err: err}
+				}
+
+				return treeResult(gr, ll1p.Production(), false)
+			}})
+
+		default:
+			a, err := lr1parser.ParseAlgorithm(name)
+			if err != nil {
+				return nil, fmt.Errorf("unknown parser %q", name)
+			}
+
+			lr1p := lr1parser.NewLR1Parser(*gr, "")
+			lr1p.SetAlgorithm(a)
+			lr1p.BuildTable()
+			if len(lr1p.Conflicts()) > 0 {
+				return nil, fmt.Errorf("the %s table has conflicts", a)
+			}
+
+			parsers = append(parsers, Parser{Name: name, Parse: func(in string) Result {
+				lr1p.SetInput(in)
+				if err := lr1p.Parse(); err != nil {
+					return Result{Err: err}
+				}
+
+				return treeResult(gr, lr1p.Production(), true)
+			}})
+		}
+	}
+
+	return parsers, nil
+}
+
+// Disagreement is an input the parsers disagree on, with the shortest
+// input found by removing symbols from it that they still disagree on.
+type Disagreement struct {
+	Input   string
+	Minimal string
+	Results []Result // results of the parsers for the minimal input
+}
+
+// run returns the results of the parsers for the input and whether the
+// decided ones disagree on acceptance or on the parse tree.
+func run(parsers []Parser, in string) ([]Result, bool) {
+	results := make([]Result, len(parsers))
+	first := -1
+	disagree := false
+
+	for i, p := range parsers {
+		results[i] = p.Parse(in)
+		if results[i].Undecided {
+			continue
+		}
+
+		if first < 0 {
+			first = i
+			continue
+		}
+
+		if results[i].Accepted != results[first].Accepted || results[i].Tree != results[first].Tree {
+			disagree = true
+		}
+	}
+
+	return results, disagree
+}
+
+// minimize removes chunks of symbols from the input, halving the chunk
+// size down to single symbols, as long as the parsers still disagree.
+func minimize(parsers []Parser, in string) (string, []Result) {
+	results, _ := run(parsers, in)
+
+	for size := len(in) / 2; size >= 1; size /= 2 {
+		for i := 0; i+size <= len(in); {
+			candidate := in[:i] + in[i+size:]
+
+			if r, disagree := run(parsers, candidate); disagree {
+				in, results = candidate, r
+				continue
+			}
+			i++
+		}
+	}
+
+	return in, results
+}
+
+// Run parses the inputs with the parsers and returns the disagreements,
+// one for each minimal input.
+func Run(parsers []Parser, inputs []string) []Disagreement {
+	var found []Disagreement
+	seen := make(map[string]bool)
+
+	for _, in := range inputs {
+		if _, disagree := run(parsers, in); !disagree {
+			continue
+		}
+
+		minimal, results := minimize(parsers, in)
+		if seen[minimal] {
+			continue
+		}
+		seen[minimal] = true
+
+		found = append(found, Disagreement{Input: in, Minimal: minimal, Results: results})
+	}
+
+	return found
+}
+
+// Inputs returns sentences of the grammar covering all its rules together
+// with mutations of them, most of which are not sentences.
+func Inputs(gr *grammar.Grammar, n int, maxDepth int, seed int64) ([]string, error) {
+	sentences, err := grammar.Generate(gr, grammar.GenerateOptions{
+		Count:    n,
+		MaxDepth: maxDepth,
+		Coverage: true,
+		Seed:     seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mutations := grammar.NearMisses(gr, sentences, seed, func(string) bool { return false })
+
+	return append(sentences, mutations...), nil
+}
+
+// Report returns the disagreements as a table with a column for each
+// parser.
+func Report(parsers []Parser, found []Disagreement) report.Table {
+	table := report.Table{
+		Title:  "Disagreements",
+		Header: []string{"Input", "Minimal"},
+	}
+	for _, p := range parsers {
+		table.Header = append(table.Header, p.Name)
+	}
+
+	for _, d := range found {
+		row := []string{d.Input, d.Minimal}
+		for _, r := range d.Results {
+			s := r.String()
+			if r.Accepted {
+				s += "\n" + strings.TrimRight(r.Tree, "\n")
+			}
+			row = append(row, s)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
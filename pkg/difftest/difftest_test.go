@@ -0,0 +1,104 @@
+package difftest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+const expr = `
+S -> E
+E -> E+T | T
+T -> T*F | F
+F -> (E) | a
+`
+
+// products is sums of products without left recursion, with the rules of
+// the nonterminals interleaved, so the lr parser decides every input.
+var products = grammar.GrammarSettings{
+	Root:      "B",
+	TSymbols:  []string{"+", "*", "a", "b", "(", ")"},
+	NTSymbols: []string{"B", "T", "M"},
+	Rules: []grammar.Rule{
+		{LSymbol: "B", RSymbol: "T+B"},
+		{LSymbol: "T", RSymbol: "M*T"},
+		{LSymbol: "M", RSymbol: "a"},
+		{LSymbol: "B", RSymbol: "T"},
+		{LSymbol: "M", RSymbol: "(B)"},
+		{LSymbol: "T", RSymbol: "M"},
+		{LSymbol: "M", RSymbol: "b"},
+	},
+}
+
+func TestRun(t *testing.T) {
+	exprGrammar, err := grammar.Read(strings.NewReader(expr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	productsGrammar, err := grammar.New(products)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		grammar  *grammar.Grammar
+		maxSteps int
+		decided  bool // whether every parser decides every input
+	}{
+		// the lr parser is stopped by the left recursion
+		{"expressions", exprGrammar, 2000, false},
+		// backtracking over the mutations takes more steps
+		{"products", productsGrammar, 200000, true},
+	}
+
+	for _, tt := range tests {
+		parsers, err := Parsers(tt.grammar, []string{"lr", "lr1", "lalr", "slr", "pager"}, tt.maxSteps, 200)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		inputs, err := Inputs(tt.grammar, 50, 8, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tt.decided {
+			accepted := 0
+			for _, in := range inputs {
+				for _, p := range parsers {
+					r := p.Parse(in)
+					if r.Undecided {
+						t.Errorf("%s: %s: %q: %v", tt.name, p.Name, in, r)
+					}
+					if r.Accepted && p.Name == "lr" {
+						accepted++
+					}
+				}
+			}
+			if accepted == 0 {
+				t.Errorf("%s: lr accepts none of %d inputs", tt.name, len(inputs))
+			}
+		}
+
+		for _, d := range Run(parsers, inputs) {
+			t.Errorf("%s: %q (minimal %q): %v", tt.name, d.Input, d.Minimal, d.Results)
+		}
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	accept := func(in string) Result { return Result{Accepted: true} }
+	noB := func(in string) Result { return Result{Accepted: !strings.Contains(in, "b")} }
+	parsers := []Parser{{"all", accept}, {"no b", noB}}
+
+	found := Run(parsers, []string{"aaa", "aabaca", "cbcb"})
+	if len(found) != 1 {
+		t.Fatalf("got %d disagreements, want 1", len(found))
+	}
+
+	if d := found[0]; d.Input != "aabaca" || d.Minimal != "b" {
+		t.Errorf("got %q minimized to %q, want %q minimized to %q", d.Input, d.Minimal, "aabaca", "b")
+	}
+}
@@ -0,0 +1,152 @@
+// Package expr translates the parse of an arithmetic expression grammar to
+// postfix notation, three-address code or a value.
+//
+// The rules of the grammar must have one of the forms
+//
+//	A -> a      an operand, a digit or a variable
+//	A -> B      a rule passing on the value of B
+//	A -> (B)    a rule passing on the value of B between two terminals
+//	A -> B+C    a binary operator
+//
+// which covers both E -> E+T and B -> T+B styles of grammars.
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+)
+
+// Expr is an expression tree. Leaves have an operand, inner nodes have an
+// operator and two operands.
+type Expr struct {
+	Op          string
+	Operand     string
+	Left, Right *Expr
+}
+
+// FromReductions returns the expression of the reductions of an LR parser,
+// as LR1Parser.Production returns them.
+func FromReductions(gr *grammar.Grammar, reductions []int) (*Expr, error) {
+	tree, err := parsetree.FromRightmost(gr, reductions)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromTree(gr, tree)
+}
+
+// FromTree returns the expression of a parse tree.
+func FromTree(gr *grammar.Grammar, n *parsetree.Node) (*Expr, error) {
+	if n.Rule < 0 {
+		return &Expr{Operand: n.Symbol}, nil
+	}
+
+	var inner []int
+	for i, c := range n.Children {
+		if c.Rule >= 0 {
+			inner = append(inner, i)
+		}
+	}
+
+	rs := gr.Rules[n.Rule].RSymbol
+	switch {
+	case len(rs) == 1 && len(inner) == 0:
+		return &Expr{Operand: rs}, nil
+
+	case len(inner) == 1 && (len(rs) == 1 || len(rs) == 3 && inner[0] == 1):
+		return FromTree(gr, n.Children[inner[0]])
+
+	case len(rs) == 3 && len(inner) == 2 && inner[0] == 0 && inner[1] == 2:
+		left, err := FromTree(gr, n.Children[0])
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := FromTree(gr, n.Children[2])
+		if err != nil {
+			return nil, err
+		}
+
+		return &Expr{Op: rs[1:2], Left: left, Right: right}, nil
+	}
+
+	return nil, fmt.Errorf("rule %d is not an expression rule: %s -> %s", n.Rule, n.Symbol, rs)
+}
+
+// RPN returns the expression in postfix notation with the operands and
+// operators separated by spaces.
+func (e *Expr) RPN() string {
+	if e.Op == "" {
+		return e.Operand
+	}
+
+	return e.Left.RPN() + " " + e.Right.RPN() + " " + e.Op
+}
+
+// TAC returns the three-address code computing the expression into the
+// temporaries t1, t2 and so on. The last one holds the value.
+func (e *Expr) TAC() []string {
+	var code []string
+
+	var gen func(e *Expr) string
+	gen = func(e *Expr) string {
+		if e.Op == "" {
+			return e.Operand
+		}
+
+		left := gen(e.Left)
+		right := gen(e.Right)
+
+		t := fmt.Sprintf("t%d", len(code)+1)
+		code = append(code, fmt.Sprintf("%s = %s %s %s", t, left, e.Op, right))
+
+		return t
+	}
+
+	if result := gen(e); len(code) == 0 {
+		code = append(code, "t1 = "+result)
+	}
+
+	return code
+}
+
+// Value computes the expression whose operands are digits. The operators
+// are +, -, * and / on integers.
+func (e *Expr) Value() (int, error) {
+	if e.Op == "" {
+		if len(e.Operand) != 1 || !strings.Contains("0123456789", e.Operand) {
+			return 0, fmt.Errorf("operand %q is not a number", e.Operand)
+		}
+
+		return int(e.Operand[0] - '0'), nil
+	}
+
+	left, err := e.Left.Value()
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := e.Right.Value()
+	if err != nil {
+		return 0, err
+	}
+
+	switch e.Op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	}
+
+	return 0, fmt.Errorf("unknown operator %q", e.Op)
+}
@@ -0,0 +1,59 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/lr1parser"
+)
+
+const arith = `
+S -> E
+E -> E+T | E-T | T
+T -> T*F | T/F | F
+F -> (E) | 1 | 2 | 3 | 4
+`
+
+func TestTranslate(t *testing.T) {
+	gr, err := grammar.Read(strings.NewReader(arith))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		rpn   string
+		tac   []string
+		value int
+	}{
+		{"4", "4", []string{"t1 = 4"}, 4},
+		{"4-2-1", "4 2 - 1 -", []string{"t1 = 4 - 2", "t2 = t1 - 1"}, 1},
+		{"1+2*3", "1 2 3 * +", []string{"t1 = 2 * 3", "t2 = 1 + t1"}, 7},
+		{"(1+2)*3/4", "1 2 + 3 * 4 /", []string{"t1 = 1 + 2", "t2 = t1 * 3", "t3 = t2 / 4"}, 2},
+	}
+
+	for _, tt := range tests {
+		lr1p := lr1parser.NewLR1Parser(*gr, tt.input)
+		if err := lr1p.Parse(); err != nil {
+			t.Fatalf("%q: %v", tt.input, err)
+		}
+
+		e, err := FromReductions(gr, lr1p.Production())
+		if err != nil {
+			t.Fatalf("%q: %v", tt.input, err)
+		}
+
+		if rpn := e.RPN(); rpn != tt.rpn {
+			t.Errorf("%q: RPN %q, want %q", tt.input, rpn, tt.rpn)
+		}
+
+		if tac := e.TAC(); strings.Join(tac, "; ") != strings.Join(tt.tac, "; ") {
+			t.Errorf("%q: TAC %q, want %q", tt.input, tac, tt.tac)
+		}
+
+		if v, err := e.Value(); err != nil || v != tt.value {
+			t.Errorf("%q: value %d, %v, want %d", tt.input, v, err, tt.value)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package grammar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Annotation tells how a rule builds an AST node: its kind and its fields,
+// which are the nodes of right side symbols. It is written after a rule in
+// grammar files as
+//
+//	E -> E+T => Add($1, $3)
+//	E -> E-T => Sub(left: $1, right: $3)
+type Annotation struct {
+	Kind   string
+	Fields []AnnotationField
+}
+
+// AnnotationField is a field of an AST node. Pos is the position of the
+// symbol in the right side, starting from 1.
+type AnnotationField struct {
+	Name string
+	Pos  int
+}
+
+func (a *Annotation) String() string {
+	fields := make([]string, len(a.Fields))
+	for i, f := range a.Fields {
+		fields[i] = fmt.Sprintf("$%d", f.Pos)
+		if f.Name != "" {
+			fields[i] = f.Name + ": " + fields[i]
+		}
+	}
+
+	return fmt.Sprintf("%s(%s)", a.Kind, strings.Join(fields, ", "))
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// parseAnnotation parses the text after =>.
+func parseAnnotation(s string) (*Annotation, error) {
+	s = strings.TrimSpace(s)
+
+	open := strings.Index(s, "(")
+	if open < 0 {
+		if !isIdent(s) {
+			return nil, fmt.Errorf("wrong annotation %q", s)
+		}
+		return &Annotation{Kind: s}, nil
+	}
+
+	a := &Annotation{Kind: strings.TrimSpace(s[:open])}
+	if !isIdent(a.Kind) || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("wrong annotation %q", s)
+	}
+
+	args := strings.TrimSpace(s[open+1 : len(s)-1])
+	if args == "" {
+		return a, nil
+	}
+
+	for _, arg := range strings.Split(args, ",") {
+		var f AnnotationField
+
+		if colon := strings.Index(arg, ":"); colon >= 0 {
+			f.Name = strings.TrimSpace(arg[:colon])
+			if !isIdent(f.Name) {
+				return nil, fmt.Errorf("wrong field name %q", f.Name)
+			}
+			arg = arg[colon+1:]
+		}
+
+		arg = strings.TrimSpace(arg)
+		pos, err := strconv.Atoi(strings.TrimPrefix(arg, "$"))
+		if !strings.HasPrefix(arg, "$") || err != nil {
+			return nil, fmt.Errorf("wrong field %q, expected $n", arg)
+		}
+		f.Pos = pos
+
+		a.Fields = append(a.Fields, f)
+	}
+
+	return a, nil
+}
+
+func (gr *Grammar) checkAnnotations() error {
+	for i, r := range gr.Rules {
+		if r.Annotation == nil {
+			continue
+		}
+
+		for _, f := range r.Annotation.Fields {
+			if f.Pos < 1 || f.Pos > len(r.RSymbol) {
+				return fmt.Errorf("rule %d: annotation %s refers to $%d", i, r.Annotation, f.Pos)
+			}
+		}
+	}
+
+	return nil
+}
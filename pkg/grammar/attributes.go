@@ -0,0 +1,112 @@
+package grammar
+
+import "fmt"
+
+// TextAttr is the attribute of a terminal that holds the terminal itself.
+const TextAttr = "text"
+
+// Attr is an attribute of a symbol of a rule. Pos 0 is the left side and
+// Pos i is the i-th symbol of the right side.
+type Attr struct {
+	Pos  int
+	Name string
+}
+
+// Equation defines an attribute of a symbol of a rule by a function of
+// other attributes of the rule's symbols. An equation for the left side
+// defines a synthesized attribute, one for a right side symbol defines an
+// inherited attribute.
+type Equation struct {
+	Target Attr
+	Args   []Attr
+	Func   func(args []Value) Value
+}
+
+// Symbol returns the symbol of the rule at the position of the attribute.
+func (r Rule) Symbol(pos int) string {
+	if pos == 0 {
+		return r.LSymbol
+	}
+
+	return r.RSymbol[pos-1 : pos]
+}
+
+// AttrString returns the attribute with the symbol it belongs to, such as
+// E.val.
+func (r Rule) AttrString(a Attr) string {
+	if a.Pos < 0 || a.Pos > len(r.RSymbol) {
+		return fmt.Sprintf("$%d.%s", a.Pos, a.Name)
+	}
+
+	return fmt.Sprintf("%s.%s", r.Symbol(a.Pos), a.Name)
+}
+
+// Inherited reports whether the attribute of the nonterminal is defined by
+// equations of the rules where the nonterminal is on the right side.
+func (gr *Grammar) Inherited(nt string, name string) bool {
+	return gr.inherited[nt][name]
+}
+
+func (gr *Grammar) checkAttributes() error {
+	gr.inherited = make(map[string]map[string]bool)
+	synthesized := make(map[string]map[string]bool)
+
+	for i, r := range gr.Rules {
+		for _, eq := range r.Equations {
+			for _, a := range append([]Attr{eq.Target}, eq.Args...) {
+				if a.Pos < 0 || a.Pos > len(r.RSymbol) {
+					return fmt.Errorf("rule %d has no symbol at position %d", i, a.Pos)
+				}
+			}
+
+			if eq.Func == nil {
+				return fmt.Errorf("rule %d: equation of %s has no function", i, r.AttrString(eq.Target))
+			}
+
+			symbol := r.Symbol(eq.Target.Pos)
+			if gr.TokenType(symbol) == Term {
+				return fmt.Errorf("rule %d: terminal attribute %s cannot be defined", i, r.AttrString(eq.Target))
+			}
+
+			kind, other := gr.inherited, synthesized
+			if eq.Target.Pos == 0 {
+				kind, other = synthesized, gr.inherited
+			}
+
+			if other[symbol][eq.Target.Name] {
+				return fmt.Errorf("attribute %s.%s is both synthesized and inherited", symbol, eq.Target.Name)
+			}
+
+			if kind[symbol] == nil {
+				kind[symbol] = make(map[string]bool)
+			}
+			kind[symbol][eq.Target.Name] = true
+		}
+	}
+
+	return nil
+}
+
+// CheckLAttributed returns an error if an inherited attribute of a right
+// side symbol depends on anything other than the inherited attributes of
+// the left side and the attributes of the symbols to its left. Such
+// definitions can be evaluated while parsing top-down.
+func (gr *Grammar) CheckLAttributed() error {
+	for i, r := range gr.Rules {
+		for _, eq := range r.Equations {
+			if eq.Target.Pos == 0 {
+				continue
+			}
+
+			for _, a := range eq.Args {
+				if a.Pos == 0 && gr.Inherited(r.LSymbol, a.Name) || a.Pos > 0 && a.Pos < eq.Target.Pos {
+					continue
+				}
+
+				return fmt.Errorf("rule %d is not L-attributed: %s depends on %s", i, r.AttrString(eq.Target), r.AttrString(a))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+)
+
+// settings makes grammar settings from a fuzzer's strings: one rule per
+// line of rules with the sides split at the first '>', and the symbols
+// declared as one character each.
+func settings(root, rules, nts, ts string) GrammarSettings {
+	gs := GrammarSettings{Root: root}
+
+	for _, line := range strings.Split(rules, "\n") {
+		sides := strings.SplitN(line, ">", 2)
+		if len(sides) != 2 {
+			continue
+		}
+		gs.Rules = append(gs.Rules, Rule{LSymbol: sides[0], RSymbol: sides[1]})
+	}
+	for _, c := range nts {
+		gs.NTSymbols = append(gs.NTSymbols, string(c))
+	}
+	for _, c := range ts {
+		gs.TSymbols = append(gs.TSymbols, string(c))
+	}
+
+	return gs
+}
+
+func FuzzNew(f *testing.F) {
+	f.Add("S", "S>E\nE>E+T\nE>T\nT>a", "SET", "+a")
+	f.Add("E", "E>TR\nR>+TR\nR>", "ER", "+T")
+	f.Add("S", "S>aSb\nS>", "S", "ab")
+	f.Add("A", "A>B\nB>A\nB>b", "AB", "b")
+	f.Add("S", "S>x\nT>y", "S", "xy")
+
+	f.Fuzz(func(t *testing.T, root, rules, nts, ts string) {
+		gr, err := New(settings(root, rules, nts, ts))
+		if err != nil {
+			return
+		}
+
+		if gr.FindNToken(gr.Root) < 0 {
+			t.Fatalf("root %q is not a nonterminal", gr.Root)
+		}
+		terminals := make(map[string]bool)
+		for _, tt := range gr.TTokens {
+			terminals[tt.TSymbol] = true
+		}
+		for i, r := range gr.Rules {
+			if gr.TokenType(r.LSymbol) != NTerm {
+				t.Fatalf("rule %d: left side %q is not a nonterminal", i, r.LSymbol)
+			}
+			for j := 0; j < len(r.RSymbol); j++ {
+				if s := r.RSymbol[j : j+1]; gr.TokenType(s) == Term && !terminals[s] {
+					t.Fatalf("rule %d: symbol %q is not declared", i, s)
+				}
+			}
+		}
+
+		for id := 0; id < gr.NumSymbols(); id++ {
+			s := gr.Symbol(id)
+			if gr.ID(s) != id {
+				t.Fatalf("symbol %q has ID %d, want %d", s, gr.ID(s), id)
+			}
+			if s != EndMarker && gr.Kind(id) != gr.TokenType(s) {
+				t.Fatalf("symbol %q is of kind %d, want %d", s, gr.Kind(id), gr.TokenType(s))
+			}
+		}
+		if n := len(gr.TTokens) + 1 + len(gr.NTokens); gr.NumSymbols() != n {
+			t.Fatalf("%d symbol IDs, want %d", gr.NumSymbols(), n)
+		}
+
+		// the transformations may fail on grammars with useless symbols, but
+		// must not panic
+		for _, nt := range gr.NTokens {
+			gr.First(nt.NTSymbol)
+			gr.Follow(nt.NTSymbol)
+		}
+		gr.LeftRecursive()
+		gr.RemoveUnitRules()
+		if useful, _, err := gr.RemoveUseless(); err == nil {
+			useful.ToGNF()
+		}
+	})
+}
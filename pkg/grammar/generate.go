@@ -0,0 +1,254 @@
+package grammar
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// GenerateOptions control the sentences made by Generate.
+type GenerateOptions struct {
+	// Count is the number of sentences.
+	Count int
+	// MaxDepth bounds the depth of the derivation trees where the grammar
+	// allows it, DefaultMaxDepth if it is 0. Deeper down the shallowest
+	// rules are used.
+	MaxDepth int
+	// Weights are the relative weights of the alternatives of a
+	// nonterminal by rule number. Rules without a weight have weight 1.
+	Weights map[int]float64
+	// Coverage makes Generate prefer unused rules and go on until every
+	// rule that can be used has been, even past Count.
+	Coverage bool
+	// Seed is the seed of the random numbers.
+	Seed int64
+}
+
+// DefaultMaxDepth is the depth bound of Generate if none is given.
+const DefaultMaxDepth = 16
+
+type generator struct {
+	grammar *Grammar
+	opts    GenerateOptions
+	rand    *rand.Rand
+	height  []int
+	used    []bool
+}
+
+// ruleHeights returns the height of the lowest derivation tree of each
+// rule, math.MaxInt32 for the rules of unproductive nonterminals.
+func (gr *Grammar) ruleHeights() []int {
+	ntHeight := make(map[string]int)
+	for _, nt := range gr.NTokens {
+		ntHeight[nt.NTSymbol] = math.MaxInt32
+	}
+
+	heights := make([]int, len(gr.Rules))
+	for changed := true; changed; {
+		changed = false
+
+		for i, r := range gr.Rules {
+			h := 1
+			for j := 0; j < len(r.RSymbol) && h < math.MaxInt32; j++ {
+				nh, ok := ntHeight[r.RSymbol[j:j+1]]
+				switch {
+				case !ok || nh < h:
+				case nh == math.MaxInt32:
+					h = math.MaxInt32
+				default:
+					h = nh + 1
+				}
+			}
+			heights[i] = h
+
+			if h < ntHeight[r.LSymbol] {
+				ntHeight[r.LSymbol] = h
+				changed = true
+			}
+		}
+	}
+
+	return heights
+}
+
+func (g *generator) weight(rule int) float64 {
+	if w, ok := g.opts.Weights[rule]; ok {
+		return w
+	}
+
+	return 1
+}
+
+// choose picks a rule of the nonterminal for a node at the depth.
+func (g *generator) choose(nt string, depth int) int {
+	alts := g.grammar.NTokens[g.grammar.FindNToken(nt)].Alt
+
+	var candidates []int
+	lowest := -1
+	for _, a := range alts {
+		if g.height[a] == math.MaxInt32 {
+			continue
+		}
+		if lowest < 0 || g.height[a] < g.height[lowest] {
+			lowest = a
+		}
+		if depth+g.height[a] <= g.opts.MaxDepth {
+			candidates = append(candidates, a)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return lowest
+	}
+
+	if g.opts.Coverage {
+		var unused []int
+		for _, a := range candidates {
+			if !g.used[a] {
+				unused = append(unused, a)
+			}
+		}
+		if len(unused) > 0 {
+			candidates = unused
+		}
+	}
+
+	total := 0.0
+	for _, a := range candidates {
+		total += g.weight(a)
+	}
+	if total <= 0 {
+		return candidates[g.rand.Intn(len(candidates))]
+	}
+
+	x := g.rand.Float64() * total
+	for _, a := range candidates {
+		if x -= g.weight(a); x < 0 {
+			return a
+		}
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+func (g *generator) expand(symbol string, depth int, b *strings.Builder) {
+	if g.grammar.TokenType(symbol) == Term {
+		b.WriteString(symbol)
+		return
+	}
+
+	r := g.choose(symbol, depth)
+	g.used[r] = true
+
+	rs := g.grammar.Rules[r].RSymbol
+	for i := 0; i < len(rs); i++ {
+		g.expand(rs[i:i+1], depth+1, b)
+	}
+}
+
+// Generate returns random sentences of the language of the grammar.
+func Generate(gr *Grammar, opts GenerateOptions) ([]string, error) {
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+
+	g := &generator{
+		grammar: gr,
+		opts:    opts,
+		rand:    rand.New(rand.NewSource(opts.Seed)),
+		height:  gr.ruleHeights(),
+		used:    make([]bool, len(gr.Rules)),
+	}
+
+	if contains(gr.Unproductive(), gr.Root) {
+		return nil, fmt.Errorf("the language of the grammar is empty")
+	}
+
+	// the rules that can be used are the productive ones of reachable
+	// nonterminals, without unproductive symbols on the right side
+	var usable []int
+	if opts.Coverage {
+		useful, origin, err := gr.RemoveUseless()
+		if err != nil {
+			return nil, err
+		}
+		for i := range useful.Rules {
+			usable = append(usable, origin[i][0])
+		}
+	}
+
+	covered := func() bool {
+		for _, r := range usable {
+			if !g.used[r] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// rules too high for the depth bound may never be covered
+	limit := opts.Count + 100*len(gr.Rules)
+
+	var sentences []string
+	for len(sentences) < opts.Count || !covered() && len(sentences) < limit {
+		var b strings.Builder
+		g.expand(gr.Root, 0, &b)
+		sentences = append(sentences, b.String())
+	}
+
+	return sentences, nil
+}
+
+// NearMisses returns strings close to the sentences that accepts rejects,
+// made by inserting, deleting, replacing or swapping terminals. A sentence
+// gets no near miss if a few tries do not give one.
+func NearMisses(gr *Grammar, sentences []string, seed int64, accepts func(string) bool) []string {
+	rnd := rand.New(rand.NewSource(seed))
+
+	var terminals []string
+	for _, t := range gr.TTokens {
+		terminals = append(terminals, t.TSymbol)
+	}
+	if len(terminals) == 0 {
+		return nil
+	}
+
+	var misses []string
+	for _, s := range sentences {
+		for try := 0; try < 10; try++ {
+			m := mutate(s, terminals, rnd)
+			if m != s && !accepts(m) {
+				misses = append(misses, m)
+				break
+			}
+		}
+	}
+
+	return misses
+}
+
+func mutate(s string, terminals []string, rnd *rand.Rand) string {
+	t := terminals[rnd.Intn(len(terminals))]
+	if len(s) == 0 {
+		return t
+	}
+
+	i := rnd.Intn(len(s))
+	switch rnd.Intn(4) {
+	case 0:
+		return s[:i] + t + s[i:]
+	case 1:
+		return s[:i] + s[i+1:]
+	case 2:
+		return s[:i] + t + s[i+1:]
+	default:
+		if len(s) < 2 {
+			return s + t
+		}
+		if i == len(s)-1 {
+			i--
+		}
+		return s[:i] + s[i+1:i+2] + s[i:i+1] + s[i+2:]
+	}
+}
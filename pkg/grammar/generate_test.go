@@ -0,0 +1,75 @@
+package grammar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// balanced reports whether s is a^n b^n for n > 0.
+func balanced(s string) bool {
+	n := len(s) / 2
+	return n > 0 && len(s) == 2*n && s == strings.Repeat("a", n)+strings.Repeat("b", n)
+}
+
+func TestGenerate(t *testing.T) {
+	gr := readString(t, "S -> aSb | ab\n")
+
+	sentences, err := Generate(gr, GenerateOptions{Count: 50, MaxDepth: 5, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sentences) != 50 {
+		t.Errorf("%d sentences, want 50", len(sentences))
+	}
+	for _, s := range sentences {
+		if !balanced(s) || len(s) > 10 {
+			t.Errorf("sentence %q", s)
+		}
+	}
+
+	again, _ := Generate(gr, GenerateOptions{Count: 50, MaxDepth: 5, Seed: 1})
+	if !reflect.DeepEqual(again, sentences) {
+		t.Error("sentences differ for the same seed")
+	}
+
+	sentences, _ = Generate(gr, GenerateOptions{Count: 10, Weights: map[int]float64{0: 0}})
+	for _, s := range sentences {
+		if s != "ab" {
+			t.Errorf("sentence %q with S -> aSb of weight 0", s)
+		}
+	}
+}
+
+func TestGenerateCoverage(t *testing.T) {
+	gr := readString(t, "S -> E\nE -> E+T | T\nT -> T*F | F\nF -> (E) | a | b\nX -> c\n")
+
+	sentences, err := Generate(gr, GenerateOptions{Count: 1, MaxDepth: 8, Coverage: true, Seed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := strings.Join(sentences, " ")
+	for _, symbol := range []string{"+", "*", "(", "a", "b"} {
+		if !strings.Contains(all, symbol) {
+			t.Errorf("no sentence uses %s: %v", symbol, sentences)
+		}
+	}
+}
+
+func TestNearMisses(t *testing.T) {
+	gr := readString(t, "S -> aSb | ab\n")
+
+	sentences, _ := Generate(gr, GenerateOptions{Count: 20, Seed: 3})
+	misses := NearMisses(gr, sentences, 3, balanced)
+
+	if len(misses) == 0 {
+		t.Fatal("no near misses")
+	}
+	for _, m := range misses {
+		if balanced(m) {
+			t.Errorf("near miss %q is a sentence", m)
+		}
+	}
+}
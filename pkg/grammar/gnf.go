@@ -0,0 +1,276 @@
+package grammar
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// TransformStep is an intermediate grammar of a conversion.
+type TransformStep struct {
+	Title   string
+	Grammar *Grammar
+}
+
+// PrintSteps prints the title and the grammar of each step.
+func PrintSteps(steps []TransformStep) {
+	for _, st := range steps {
+		report.Write(os.Stdout, report.Color, report.Table{Title: st.Title, Rows: [][]string{{}}})
+		st.Grammar.Print()
+		fmt.Println()
+	}
+}
+
+// freshSymbols are the candidates for new nonterminals.
+const freshSymbols = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// bodies holds the right sides of the rules of each nonterminal in the
+// order of the nonterminals, as the conversions rewrite them.
+type bodies struct {
+	order []string
+	rs    map[string][]string
+	used  map[string]bool
+}
+
+func (gr *Grammar) bodies() *bodies {
+	b := &bodies{rs: make(map[string][]string), used: make(map[string]bool)}
+
+	for _, nt := range gr.NTokens {
+		b.order = append(b.order, nt.NTSymbol)
+		b.used[nt.NTSymbol] = true
+	}
+	for _, t := range gr.TTokens {
+		b.used[t.TSymbol] = true
+	}
+	for _, r := range gr.Rules {
+		b.rs[r.LSymbol] = append(b.rs[r.LSymbol], r.RSymbol)
+	}
+
+	return b
+}
+
+// fresh returns an unused uppercase letter for a new nonterminal.
+func (b *bodies) fresh() (string, error) {
+	for _, c := range freshSymbols {
+		if s := string(c); !b.used[s] {
+			b.used[s] = true
+			b.order = append(b.order, s)
+			return s, nil
+		}
+	}
+
+	return "", fmt.Errorf("no symbols left for new nonterminals")
+}
+
+// substitute returns the right sides with a leading nt replaced by each
+// of its right sides.
+func (b *bodies) substitute(rs []string, nt string) []string {
+	var substituted []string
+
+	for _, r := range rs {
+		if !strings.HasPrefix(r, nt) {
+			substituted = appendUnique(substituted, r)
+			continue
+		}
+
+		for _, s := range b.rs[nt] {
+			substituted = appendUnique(substituted, s+r[1:])
+		}
+	}
+
+	return substituted
+}
+
+func appendUnique(set []string, s string) []string {
+	if contains(set, s) {
+		return set
+	}
+
+	return append(set, s)
+}
+
+func (b *bodies) grammar(gr *Grammar) (*Grammar, error) {
+	var rules []Rule
+	for _, nt := range b.order {
+		for _, r := range b.rs[nt] {
+			rules = append(rules, Rule{LSymbol: nt, RSymbol: r})
+		}
+	}
+
+	return gr.derive(rules)
+}
+
+func (gr *Grammar) checkEpsilonFree() error {
+	for i, r := range gr.Rules {
+		if r.RSymbol == "" {
+			return fmt.Errorf("rule %d: %s -> %s, the grammar must be ε-free", i, r.LSymbol, Epsilon)
+		}
+	}
+
+	return nil
+}
+
+// RemoveLeftRecursion returns an equivalent grammar without left recursion
+// for an ε-free grammar without cycles A =>+ A. The nonterminals are taken
+// in order, the rules of each one starting with an earlier one are
+// substituted, and immediate left recursion A -> Aα | β is replaced by
+// A -> β | βA', A' -> α | αA' with a new nonterminal A'.
+func (gr *Grammar) RemoveLeftRecursion() (*Grammar, error) {
+	if err := gr.checkEpsilonFree(); err != nil {
+		return nil, err
+	}
+
+	b := gr.bodies()
+	original := append([]string(nil), b.order...)
+
+	for i, ai := range original {
+		for _, aj := range original[:i] {
+			b.rs[ai] = b.substitute(b.rs[ai], aj)
+		}
+
+		var alphas, betas []string
+		for _, r := range b.rs[ai] {
+			if strings.HasPrefix(r, ai) {
+				alphas = append(alphas, r[1:])
+			} else {
+				betas = append(betas, r)
+			}
+		}
+
+		if len(alphas) == 0 {
+			continue
+		}
+		if len(betas) == 0 {
+			return nil, fmt.Errorf("every rule of %s is left recursive", ai)
+		}
+		if contains(alphas, "") {
+			return nil, fmt.Errorf("the grammar has a cycle %s =>+ %s", ai, ai)
+		}
+
+		n, err := b.fresh()
+		if err != nil {
+			return nil, err
+		}
+
+		b.rs[ai] = nil
+		for _, beta := range betas {
+			b.rs[ai] = append(b.rs[ai], beta, beta+n)
+		}
+		for _, alpha := range alphas {
+			b.rs[n] = append(b.rs[n], alpha, alpha+n)
+		}
+	}
+
+	return b.grammar(gr)
+}
+
+// ToGNF converts an ε-free grammar to Greibach normal form, where every
+// right side is a terminal followed by nonterminals. It returns the grammar
+// after each step of the conversion as well.
+func (gr *Grammar) ToGNF() (*Grammar, []TransformStep, error) {
+	if err := gr.checkEpsilonFree(); err != nil {
+		return nil, nil, err
+	}
+
+	steps := []TransformStep{{"Grammar", gr}}
+
+	g, _, err := gr.RemoveUnitRules()
+	if err != nil {
+		return nil, nil, err
+	}
+	steps = append(steps, TransformStep{"Unit rules removed", g})
+
+	g, _, err = g.RemoveUseless()
+	if err != nil {
+		return nil, nil, err
+	}
+	steps = append(steps, TransformStep{"Useless symbols removed", g})
+
+	g, err = g.RemoveLeftRecursion()
+	if err != nil {
+		return nil, nil, err
+	}
+	steps = append(steps, TransformStep{"Left recursion removed", g})
+
+	// without left recursion the leading nonterminals can be substituted
+	// starting with the ones whose rules all start with a terminal
+	b := g.bodies()
+	for done := make(map[string]bool); len(done) < len(b.order); {
+		progress := false
+
+		for _, nt := range b.order {
+			if done[nt] {
+				continue
+			}
+
+			ready := true
+			for _, r := range b.rs[nt] {
+				if lead := r[:1]; g.TokenType(lead) == NTerm && !done[lead] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			for _, r := range b.rs[nt] {
+				if lead := r[:1]; g.TokenType(lead) == NTerm {
+					b.rs[nt] = b.substitute(b.rs[nt], lead)
+				}
+			}
+			done[nt] = true
+			progress = true
+		}
+
+		if !progress {
+			return nil, nil, fmt.Errorf("leading nonterminals cannot be substituted")
+		}
+	}
+
+	g, err = b.grammar(g)
+	if err != nil {
+		return nil, nil, err
+	}
+	steps = append(steps, TransformStep{"Leading nonterminals substituted", g})
+
+	// terminals after the first one get nonterminals of their own
+	b = g.bodies()
+	replace := make(map[string]string)
+	for _, nt := range append([]string(nil), b.order...) {
+		for i, r := range b.rs[nt] {
+			var body strings.Builder
+			body.WriteString(r[:1])
+
+			for j := 1; j < len(r); j++ {
+				symbol := r[j : j+1]
+				if g.TokenType(symbol) == NTerm {
+					body.WriteString(symbol)
+					continue
+				}
+
+				if replace[symbol] == "" {
+					n, err := b.fresh()
+					if err != nil {
+						return nil, nil, err
+					}
+					replace[symbol] = n
+					b.rs[n] = []string{symbol}
+				}
+				body.WriteString(replace[symbol])
+			}
+
+			b.rs[nt][i] = body.String()
+		}
+	}
+
+	g, err = b.grammar(g)
+	if err != nil {
+		return nil, nil, err
+	}
+	steps = append(steps, TransformStep{"Greibach normal form", g})
+
+	return g, steps, nil
+}
@@ -0,0 +1,58 @@
+package grammar_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/internal/goldentest"
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+func originTable(origin grammar.Origin) report.Table {
+	table := report.Table{
+		Title:  "Origin",
+		Header: []string{"#", "Original rules"},
+	}
+	for i := range origin {
+		table.Rows = append(table.Rows, []string{fmt.Sprintf("%d", i), fmt.Sprintf("%v", origin[i])})
+	}
+
+	return table
+}
+
+func TestGolden(t *testing.T) {
+	goldentest.Run(t, goldentest.Dir, func(t *testing.T, c *goldentest.Case) map[string]string {
+		gr := c.Grammar
+
+		var nts []string
+		for _, nt := range gr.NTokens {
+			nts = append(nts, nt.NTSymbol)
+		}
+		out := map[string]string{
+			"grammar": goldentest.Text(append(gr.Tables(), gr.FirstTable(nts...), gr.FollowTable())...),
+		}
+
+		simple, origin, err := gr.RemoveUnitRules()
+		if err == nil {
+			var o grammar.Origin
+			simple, o, err = simple.RemoveUseless()
+			if err == nil {
+				origin = origin.Compose(o)
+			}
+		}
+		if err != nil {
+			out["simplify"] = fmt.Sprintln(err)
+		} else {
+			out["simplify"] = goldentest.Text(append(simple.Tables(), originTable(origin))...)
+		}
+
+		if gnf, _, err := gr.ToGNF(); err != nil {
+			out["gnf"] = fmt.Sprintln(err)
+		} else {
+			out["gnf"] = goldentest.Text(gnf.Tables()...)
+		}
+
+		return out
+	})
+}
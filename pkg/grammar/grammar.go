@@ -3,10 +3,10 @@ package grammar
 import (
 	"fmt"
 	"os"
-
-	"github.com/olekukonko/tablewriter"
+	"strings"
 
 	"github.com/svkirillov/translator-labs/pkg/helpers"
+	"github.com/svkirillov/translator-labs/pkg/report"
 )
 
 // Type of token
@@ -15,10 +15,32 @@ const (
 	NTerm
 )
 
+const (
+	// Epsilon stands for the empty string in FIRST sets and grammar files.
+	Epsilon = "ε"
+	// EndMarker is the end of input symbol of the parsers. It is not an
+	// ASCII character, so no grammar symbol or input character equals it.
+	EndMarker = "⊣"
+)
+
+// Value is a semantic value of a grammar symbol.
+type Value interface{}
+
+// Action is a semantic action of a rule. It gets the values of the right
+// side symbols and returns the value of the left side symbol.
+type Action func(args []Value) Value
+
 // Grammar's rule
 type Rule struct {
 	LSymbol string
 	RSymbol string
+	Action  Action
+
+	// Equations define the attributes of the rule's symbols.
+	Equations []Equation
+
+	// Annotation is the AST node built by the rule, nil for none.
+	Annotation *Annotation
 }
 
 // Terminal symbol
@@ -38,6 +60,19 @@ type Grammar struct {
 	TTokens []TToken
 	NTokens []NToken
 	Rules   []Rule
+
+	// ids and names intern the symbols, see ID, and lhs and rhs hold the
+	// sides of the rules by the IDs of their symbols.
+	ids   map[string]int
+	names []string
+	lhs   []int
+	rhs   [][]int
+
+	nullable map[string]bool
+	first    map[string][]string
+	follow   map[string][]string
+
+	inherited map[string]map[string]bool
 }
 
 type GrammarSettings struct {
@@ -65,8 +100,11 @@ func New(gs GrammarSettings) (*Grammar, error) {
 		newGrammar.Rules = append(
 			newGrammar.Rules,
 			Rule{
-				LSymbol: ls,
-				RSymbol: rs,
+				LSymbol:    ls,
+				RSymbol:    rs,
+				Action:     gs.Rules[i].Action,
+				Equations:  gs.Rules[i].Equations,
+				Annotation: gs.Rules[i].Annotation,
 			},
 		)
 	}
@@ -106,19 +144,158 @@ func New(gs GrammarSettings) (*Grammar, error) {
 		)
 	}
 
+	newGrammar.intern()
+
+	if err := newGrammar.checkSymbols(); err != nil {
+		return nil, err
+	}
+
+	if err := newGrammar.checkAttributes(); err != nil {
+		return nil, err
+	}
+
+	if err := newGrammar.checkAnnotations(); err != nil {
+		return nil, err
+	}
+
+	newGrammar.computeFirst()
+	newGrammar.computeFollow()
+
 	return &newGrammar, nil
 }
 
-func (gr *Grammar) FindNToken(token string) int {
-	for i, nt := range gr.NTokens {
-		if nt.NTSymbol == token {
-			return i
+// checkSymbols checks that the symbols are single characters, that none is
+// both a terminal and a nonterminal, that the root and the left sides are
+// nonterminals and that the right sides use declared symbols only.
+func (gr *Grammar) checkSymbols() error {
+	declared := make(map[string]bool)
+	for _, tt := range gr.TTokens {
+		declared[tt.TSymbol] = true
+	}
+
+	for _, nt := range gr.NTokens {
+		if declared[nt.NTSymbol] {
+			return fmt.Errorf("symbol %s is both a terminal and a nonterminal", nt.NTSymbol)
+		}
+		declared[nt.NTSymbol] = true
+	}
+
+	for s := range declared {
+		if len(s) != 1 {
+			return fmt.Errorf("symbol %q is not a single character", s)
 		}
 	}
 
+	if gr.FindNToken(gr.Root) < 0 {
+		return fmt.Errorf("root %q is not a nonterminal", gr.Root)
+	}
+
+	for i, r := range gr.Rules {
+		if gr.FindNToken(r.LSymbol) < 0 {
+			return fmt.Errorf("rule %d: left side %q is not a nonterminal", i, r.LSymbol)
+		}
+
+		for j := 0; j < len(r.RSymbol); j++ {
+			if s := r.RSymbol[j : j+1]; !declared[s] {
+				return fmt.Errorf("rule %d: symbol %q is not declared", i, s)
+			}
+		}
+	}
+
+	return nil
+}
+
+// intern numbers the symbols for ID and the symbols of the rules. The
+// symbols that are not declared get -1, which checkSymbols rejects.
+func (gr *Grammar) intern() {
+	gr.names = nil
+	for _, tt := range gr.TTokens {
+		gr.names = append(gr.names, tt.TSymbol)
+	}
+	gr.names = append(gr.names, EndMarker)
+	for _, nt := range gr.NTokens {
+		gr.names = append(gr.names, nt.NTSymbol)
+	}
+
+	gr.ids = make(map[string]int, len(gr.names))
+	for id, name := range gr.names {
+		if _, ok := gr.ids[name]; !ok {
+			gr.ids[name] = id
+		}
+	}
+
+	gr.lhs = make([]int, len(gr.Rules))
+	gr.rhs = make([][]int, len(gr.Rules))
+	for i, r := range gr.Rules {
+		gr.lhs[i] = gr.ID(r.LSymbol)
+		gr.rhs[i] = make([]int, len(r.RSymbol))
+		for j := range r.RSymbol {
+			gr.rhs[i][j] = gr.ID(r.RSymbol[j : j+1])
+		}
+	}
+}
+
+// ID returns the number of the symbol. The terminals are numbered in the
+// order of TTokens from 0, the end marker follows them and then come the
+// nonterminals in the order of NTokens. It returns -1 for other symbols.
+func (gr *Grammar) ID(symbol string) int {
+	id, ok := gr.ids[symbol]
+	if !ok {
+		return -1
+	}
+
+	return id
+}
+
+// Symbol returns the symbol numbered id.
+func (gr *Grammar) Symbol(id int) string {
+	return gr.names[id]
+}
+
+// NumSymbols returns the number of symbols with an ID, which are the
+// terminals, the end marker and the nonterminals.
+func (gr *Grammar) NumSymbols() int {
+	return len(gr.names)
+}
+
+// LeftID returns the ID of the left side of rule r.
+func (gr *Grammar) LeftID(r int) int {
+	return gr.lhs[r]
+}
+
+// RightIDs returns the IDs of the symbols of the right side of rule r,
+// which is empty for an ε-rule. The slice must not be modified.
+func (gr *Grammar) RightIDs(r int) []int {
+	return gr.rhs[r]
+}
+
+// Kind returns Term for the terminals and the end marker and NTerm for the
+// nonterminals.
+func (gr *Grammar) Kind(id int) int {
+	if id <= len(gr.TTokens) {
+		return Term
+	}
+
+	return NTerm
+}
+
+// FindNToken returns the index of the nonterminal in NTokens or -1.
+func (gr *Grammar) FindNToken(token string) int {
+	return gr.NTokenIndex(gr.ID(token))
+}
+
+// NTokenIndex returns the index in NTokens of the nonterminal with the ID
+// or -1.
+func (gr *Grammar) NTokenIndex(id int) int {
+	if id > len(gr.TTokens) {
+		return id - len(gr.TTokens) - 1
+	}
+
 	return -1
 }
 
+// TokenType returns NTerm for the nonterminals and Term for the other
+// symbols.
 func (gr *Grammar) TokenType(symbol string) int {
 	if gr.FindNToken(symbol) < 0 {
 		return Term
@@ -127,49 +304,43 @@ func (gr *Grammar) TokenType(symbol string) int {
 	return NTerm
 }
 
-func (gr *Grammar) Print() {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetBorder(false)
-
-	table.SetHeader([]string{"#", "Rule"})
-
-	data := make([][]string, 0)
+// Tables returns the rules, the start symbol, the terminals and the
+// nonterminals of the grammar.
+func (gr *Grammar) Tables() []report.Table {
+	rules := report.Table{
+		Title:  "Rules",
+		Header: []string{"#", "Rule"},
+	}
 	for i, r := range gr.Rules {
-		data = append(
-			data,
+		rule := fmt.Sprintf("%s -> %s", r.LSymbol, rhs(r.RSymbol))
+		if r.Annotation != nil {
+			rule += " => " + r.Annotation.String()
+		}
+
+		rules.Rows = append(
+			rules.Rows,
 			[]string{
 				fmt.Sprintf("%d", i),
-				fmt.Sprintf("%s -> %s", r.LSymbol, r.RSymbol),
+				rule,
 			},
 		)
 	}
 
-	table.AppendBulk(data)
-
-	fmt.Println("\033[1mRules:\033[0m")
-	table.Render()
-
-	fmt.Printf("\033[1mStart symbol:\033[0m %s\n", gr.Root)
-
-	fmt.Printf("\033[1mTerminal symbols:\033[0m")
+	terms := report.Table{
+		Title: "Terminal symbols",
+		Rows:  [][]string{{}},
+	}
 	for _, tt := range gr.TTokens {
-		fmt.Printf(" %s", tt.TSymbol)
+		terms.Rows[0] = append(terms.Rows[0], tt.TSymbol)
 	}
-	fmt.Println()
-
-	table = tablewriter.NewWriter(os.Stdout)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetBorder(false)
 
-	table.SetHeader([]string{"Symbol", "Qty of alts", "Alternatives"})
-
-	data = make([][]string, 0)
+	nonTerms := report.Table{
+		Title:  "Non terminal symbols",
+		Header: []string{"Symbol", "Qty of alts", "Alternatives"},
+	}
 	for _, nt := range gr.NTokens {
-		data = append(
-			data,
+		nonTerms.Rows = append(
+			nonTerms.Rows,
 			[]string{
 				nt.NTSymbol,
 				fmt.Sprintf("%d", nt.AltCount),
@@ -178,8 +349,53 @@ func (gr *Grammar) Print() {
 		)
 	}
 
-	table.AppendBulk(data)
+	return []report.Table{
+		rules,
+		{
+			Title: "Start symbol",
+			Rows:  [][]string{{gr.Root}},
+		},
+		terms,
+		nonTerms,
+	}
+}
+
+// FirstTable returns the FIRST sets of the strings of symbols.
+func (gr *Grammar) FirstTable(strs ...string) report.Table {
+	table := report.Table{
+		Title:  "FIRST",
+		Header: []string{"Symbols", "FIRST"},
+	}
+
+	for _, s := range strs {
+		table.Rows = append(table.Rows, []string{rhs(s), strings.Join(gr.First(s), " ")})
+	}
+
+	return table
+}
+
+// FollowTable returns the FOLLOW sets of the nonterminals.
+func (gr *Grammar) FollowTable() report.Table {
+	table := report.Table{
+		Title:  "FOLLOW",
+		Header: []string{"Symbol", "FOLLOW"},
+	}
+
+	for _, nt := range gr.NTokens {
+		table.Rows = append(table.Rows, []string{nt.NTSymbol, strings.Join(gr.Follow(nt.NTSymbol), " ")})
+	}
+
+	return table
+}
+
+func (gr *Grammar) Print() {
+	report.Write(os.Stdout, report.Color, gr.Tables()...)
+}
+
+func rhs(symbols string) string {
+	if symbols == "" {
+		return Epsilon
+	}
 
-	fmt.Println("\033[1mNon terminal symbols:\033[0m")
-	table.Render()
+	return symbols
 }
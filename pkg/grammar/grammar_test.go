@@ -0,0 +1,95 @@
+package grammar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewDeclarationOrder(t *testing.T) {
+	gs := GrammarSettings{
+		Root:      "S",
+		TSymbols:  []string{"+", "*", "(", ")", "a", "b", "+", "c", "d"},
+		NTSymbols: []string{"S", "E", "T", "F", "E"},
+		Rules: []Rule{
+			{LSymbol: "S", RSymbol: "E"},
+			{LSymbol: "E", RSymbol: "E+T"},
+			{LSymbol: "E", RSymbol: "T"},
+			{LSymbol: "T", RSymbol: "T*F"},
+			{LSymbol: "T", RSymbol: "F"},
+			{LSymbol: "F", RSymbol: "(E)"},
+			{LSymbol: "F", RSymbol: "a"},
+			{LSymbol: "F", RSymbol: "b"},
+			{LSymbol: "F", RSymbol: "c"},
+			{LSymbol: "F", RSymbol: "d"},
+		},
+	}
+
+	wantT := []string{"+", "*", "(", ")", "a", "b", "c", "d"}
+	wantNT := []string{"S", "E", "T", "F"}
+
+	// map iteration order changes from run to run, so a single run could
+	// be in order by chance
+	for i := 0; i < 20; i++ {
+		gr, err := New(gs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ts, nts []string
+		for _, tt := range gr.TTokens {
+			ts = append(ts, tt.TSymbol)
+		}
+		for _, nt := range gr.NTokens {
+			nts = append(nts, nt.NTSymbol)
+		}
+
+		if !reflect.DeepEqual(ts, wantT) {
+			t.Fatalf("terminals %v, want %v", ts, wantT)
+		}
+		if !reflect.DeepEqual(nts, wantNT) {
+			t.Fatalf("nonterminals %v, want %v", nts, wantNT)
+		}
+	}
+}
+
+func TestRuleIDs(t *testing.T) {
+	gr, err := New(GrammarSettings{
+		Root:      "S",
+		TSymbols:  []string{"a", "b"},
+		NTSymbols: []string{"S", "A"},
+		Rules: []Rule{
+			{LSymbol: "S", RSymbol: "aAb"},
+			{LSymbol: "A", RSymbol: "AS"},
+			{LSymbol: "A", RSymbol: ""},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a and b are 0 and 1, the end marker 2, S and A 3 and 4
+	tests := []struct {
+		left  int
+		right []int
+	}{
+		{3, []int{0, 4, 1}},
+		{4, []int{4, 3}},
+		{4, []int{}},
+	}
+
+	for r, tt := range tests {
+		if got := gr.LeftID(r); got != tt.left {
+			t.Errorf("rule %d: left side %d, want %d", r, got, tt.left)
+		}
+		if got := gr.RightIDs(r); !reflect.DeepEqual(got, tt.right) {
+			t.Errorf("rule %d: right side %v, want %v", r, got, tt.right)
+		}
+	}
+
+	if got := gr.NTokenIndex(gr.ID("A")); got != 1 {
+		t.Errorf("NTokenIndex(A) = %d, want 1", got)
+	}
+	if got := gr.NTokenIndex(gr.ID("a")); got != -1 {
+		t.Errorf("NTokenIndex(a) = %d, want -1", got)
+	}
+}
@@ -0,0 +1,203 @@
+package grammar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Read reads a grammar in the following text format:
+//
+//	# comment
+//	S -> E
+//	E -> E+T | T
+//	  | 'x'
+//
+// Every symbol is a single character and whitespace between symbols is
+// ignored. The left side of the first rule is the root, the left sides of all
+// rules are the nonterminals and all other symbols are terminals. A quoted
+// character is always a terminal, ε or an empty alternative is the empty
+// string, and a line starting with | continues the previous rule. An
+// alternative may end with an Annotation after whitespace and =>, such as
+//
+//	E -> E+T => Add($1, $3)
+func Read(r io.Reader) (*Grammar, error) {
+	var gs GrammarSettings
+	var quoted []string
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	ls := ""
+
+	for scanner.Scan() {
+		lineNum++
+
+		line, err := stripComment(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var body string
+		if strings.HasPrefix(line, "|") {
+			if ls == "" {
+				return nil, fmt.Errorf("line %d: alternative without a rule", lineNum)
+			}
+			body = line[1:]
+		} else {
+			arrow := strings.Index(line, "->")
+			if arrow < 0 {
+				return nil, fmt.Errorf("line %d: expected '->'", lineNum)
+			}
+
+			ls = strings.TrimSpace(line[:arrow])
+			if len(ls) != 1 || ls == "'" || ls == "|" || ls[0] >= utf8.RuneSelf {
+				return nil, fmt.Errorf("line %d: wrong left side %q", lineNum, ls)
+			}
+
+			if gs.Root == "" {
+				gs.Root = ls
+			}
+			gs.NTSymbols = append(gs.NTSymbols, ls)
+			body = line[arrow+2:]
+		}
+
+		alts, annotations, q, err := splitAlternatives(body)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+
+		quoted = append(quoted, q...)
+		for i, rs := range alts {
+			gs.Rules = append(gs.Rules, Rule{LSymbol: ls, RSymbol: rs, Annotation: annotations[i]})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(gs.Rules) == 0 {
+		return nil, fmt.Errorf("grammar has no rules")
+	}
+
+	nts := make(map[string]bool)
+	for _, nt := range gs.NTSymbols {
+		nts[nt] = true
+	}
+
+	for _, q := range quoted {
+		if nts[q] {
+			return nil, fmt.Errorf("quoted symbol %q is a nonterminal", q)
+		}
+	}
+
+	for _, r := range gs.Rules {
+		for i := 0; i < len(r.RSymbol); i++ {
+			if symbol := r.RSymbol[i : i+1]; !nts[symbol] {
+				gs.TSymbols = append(gs.TSymbols, symbol)
+			}
+		}
+	}
+
+	return New(gs)
+}
+
+// ReadFile reads a grammar from the named file, or from the standard input
+// if the name is "-".
+func ReadFile(name string) (*Grammar, error) {
+	if name == "-" {
+		return Read(os.Stdin)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return gr, nil
+}
+
+func stripComment(line string) (string, error) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\'':
+			if i+2 >= len(line) || line[i+2] != '\'' {
+				return "", fmt.Errorf("unterminated quote")
+			}
+			i += 2
+		case '#':
+			return line[:i], nil
+		}
+	}
+
+	return line, nil
+}
+
+// splitAlternatives splits the right sides of a rule and returns them with
+// their annotations and the quoted symbols. An annotation starts with =>
+// after whitespace and goes on to the next alternative.
+func splitAlternatives(body string) ([]string, []*Annotation, []string, error) {
+	var alts, quoted []string
+	var annotations []*Annotation
+	var annotation *Annotation
+	var rs strings.Builder
+
+	for i := 0; i < len(body); {
+		r, size := utf8.DecodeRuneInString(body[i:])
+
+		switch {
+		case r == '|':
+			alts = append(alts, rs.String())
+			annotations = append(annotations, annotation)
+			rs.Reset()
+			annotation = nil
+		case r == ' ' || r == '\t':
+		case string(r) == Epsilon:
+		case r == '=' && strings.HasPrefix(body[i:], "=>") && (i == 0 || body[i-1] == ' ' || body[i-1] == '\t'):
+			end := strings.Index(body[i:], "|")
+			if end < 0 {
+				end = len(body) - i
+			}
+
+			a, err := parseAnnotation(body[i+2 : i+end])
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			annotation = a
+			size = end
+		case r == '\'':
+			symbol := body[i+1 : i+2]
+			if symbol[0] >= utf8.RuneSelf {
+				return nil, nil, nil, fmt.Errorf("symbols must be ASCII characters")
+			}
+			quoted = append(quoted, symbol)
+			rs.WriteString(symbol)
+			size = 3
+		case r >= utf8.RuneSelf:
+			return nil, nil, nil, fmt.Errorf("symbols must be ASCII characters: %q", r)
+		default:
+			rs.WriteRune(r)
+		}
+
+		i += size
+	}
+
+	alts = append(alts, rs.String())
+	annotations = append(annotations, annotation)
+
+	return alts, annotations, quoted, nil
+}
@@ -0,0 +1,103 @@
+package grammar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	gr, err := Read(strings.NewReader(`
+# sums of products
+S -> E
+E -> E+T | T   # left recursive
+T -> T'*'F
+  | F
+F -> (E) | a | ε
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gr.Root != "S" {
+		t.Errorf("root %q, want S", gr.Root)
+	}
+
+	var ts, nts []string
+	for _, tt := range gr.TTokens {
+		ts = append(ts, tt.TSymbol)
+	}
+	for _, nt := range gr.NTokens {
+		nts = append(nts, nt.NTSymbol)
+	}
+	if got := strings.Join(ts, " "); got != "+ * ( ) a" {
+		t.Errorf("terminals %s, want + * ( ) a", got)
+	}
+	if got := strings.Join(nts, " "); got != "S E T F" {
+		t.Errorf("nonterminals %s, want S E T F", got)
+	}
+
+	var rules []string
+	for _, r := range gr.Rules {
+		rules = append(rules, r.LSymbol+">"+r.RSymbol)
+	}
+	want := "S>E E>E+T E>T T>T*F T>F F>(E) F>a F>"
+	if got := strings.Join(rules, " "); got != want {
+		t.Errorf("rules %s, want %s", got, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar string
+		err     string
+	}{
+		{"no rules", "# nothing\n\n", "grammar has no rules"},
+		{"no arrow", "S -> a\nS a\n", "line 2: expected '->'"},
+		{"alternative first", "| a\nS -> a\n", "line 1: alternative without a rule"},
+		{"long left side", "SS -> a\n", "line 1: wrong left side"},
+		{"quoted left side", "'a' -> b\n", "line 1: wrong left side"},
+		{"unterminated quote", "S -> 'a\n", "line 1: unterminated quote"},
+		{"quoted nonterminal", "S -> 'A'\nA -> a\n", "quoted symbol \"A\" is a nonterminal"},
+		{"not ASCII", "S -> aя\n", "line 1: symbols must be ASCII characters"},
+	}
+
+	for _, tt := range tests {
+		_, err := Read(strings.NewReader(tt.grammar))
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %q, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := ReadFile(filepath.Join(dir, "missing.txt")); !os.IsNotExist(err) {
+		t.Errorf("missing file: error %v, want not exist", err)
+	}
+
+	name := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(name, []byte("S -> a\nS\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(name); err == nil || !strings.HasPrefix(err.Error(), name+": line 2:") {
+		t.Errorf("bad file: error %v, want it prefixed with the name and line", err)
+	}
+
+	name = filepath.Join(dir, "good.txt")
+	if err := os.WriteFile(name, []byte("S -> aS | b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if gr, err := ReadFile(name); err != nil {
+		t.Error(err)
+	} else if len(gr.Rules) != 2 {
+		t.Errorf("%d rules, want 2", len(gr.Rules))
+	}
+}
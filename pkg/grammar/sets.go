@@ -0,0 +1,277 @@
+package grammar
+
+func (gr *Grammar) computeFirst() {
+	gr.nullable = make(map[string]bool)
+	gr.first = make(map[string][]string)
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, r := range gr.Rules {
+			if !gr.nullable[r.LSymbol] && gr.nullableString(r.RSymbol) {
+				gr.nullable[r.LSymbol] = true
+				changed = true
+			}
+
+			for _, t := range gr.firstString(r.RSymbol) {
+				if t == Epsilon || contains(gr.first[r.LSymbol], t) {
+					continue
+				}
+
+				gr.first[r.LSymbol] = append(gr.first[r.LSymbol], t)
+				changed = true
+			}
+		}
+	}
+
+	for nt := range gr.first {
+		gr.first[nt] = gr.sortTerminals(gr.first[nt])
+	}
+}
+
+func (gr *Grammar) computeFollow() {
+	gr.follow = make(map[string][]string)
+	gr.follow[gr.Root] = []string{EndMarker}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, r := range gr.Rules {
+			for i := 0; i < len(r.RSymbol); i++ {
+				symbol := r.RSymbol[i : i+1]
+				if gr.TokenType(symbol) != NTerm {
+					continue
+				}
+
+				tail := r.RSymbol[i+1:]
+				add := gr.firstString(tail)
+				if gr.nullableString(tail) {
+					add = append(add, gr.follow[r.LSymbol]...)
+				}
+
+				for _, t := range add {
+					if t == Epsilon || contains(gr.follow[symbol], t) {
+						continue
+					}
+
+					gr.follow[symbol] = append(gr.follow[symbol], t)
+					changed = true
+				}
+			}
+		}
+	}
+
+	for nt := range gr.follow {
+		gr.follow[nt] = gr.sortTerminals(gr.follow[nt])
+	}
+}
+
+func (gr *Grammar) nullableString(symbols string) bool {
+	for i := 0; i < len(symbols); i++ {
+		if !gr.nullable[symbols[i:i+1]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (gr *Grammar) firstString(symbols string) []string {
+	f := make([]string, 0)
+
+	for i := 0; i < len(symbols); i++ {
+		symbol := symbols[i : i+1]
+
+		if gr.TokenType(symbol) == Term {
+			return append(f, symbol)
+		}
+
+		for _, t := range gr.first[symbol] {
+			if !contains(f, t) {
+				f = append(f, t)
+			}
+		}
+
+		if !gr.nullable[symbol] {
+			return f
+		}
+	}
+
+	return f
+}
+
+// sortTerminals orders a set of terminals as they are declared, followed by
+// the end marker and Epsilon.
+func (gr *Grammar) sortTerminals(set []string) []string {
+	sorted := make([]string, 0, len(set))
+
+	for _, tt := range gr.TTokens {
+		if contains(set, tt.TSymbol) {
+			sorted = append(sorted, tt.TSymbol)
+		}
+	}
+
+	for _, t := range set {
+		if !contains(sorted, t) && t != Epsilon {
+			sorted = append(sorted, t)
+		}
+	}
+
+	if contains(set, Epsilon) {
+		sorted = append(sorted, Epsilon)
+	}
+
+	return sorted
+}
+
+// Nullable reports whether the string of symbols derives the empty string.
+func (gr *Grammar) Nullable(symbols string) bool {
+	return gr.nullableString(symbols)
+}
+
+// First returns the FIRST set of a string of symbols. It contains Epsilon
+// if the string is nullable.
+func (gr *Grammar) First(symbols string) []string {
+	f := gr.firstString(symbols)
+	if gr.nullableString(symbols) {
+		f = append(f, Epsilon)
+	}
+
+	return gr.sortTerminals(f)
+}
+
+// Follow returns the FOLLOW set of a nonterminal. The FOLLOW set of the root
+// contains EndMarker.
+func (gr *Grammar) Follow(nt string) []string {
+	return append([]string(nil), gr.follow[nt]...)
+}
+
+// Unproductive returns the nonterminals that derive no terminal string.
+func (gr *Grammar) Unproductive() []string {
+	productive := make(map[string]bool)
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, r := range gr.Rules {
+			if productive[r.LSymbol] {
+				continue
+			}
+
+			ok := true
+			for i := 0; i < len(r.RSymbol); i++ {
+				symbol := r.RSymbol[i : i+1]
+				if gr.TokenType(symbol) == NTerm && !productive[symbol] {
+					ok = false
+					break
+				}
+			}
+
+			if ok {
+				productive[r.LSymbol] = true
+				changed = true
+			}
+		}
+	}
+
+	var unproductive []string
+	for _, nt := range gr.NTokens {
+		if !productive[nt.NTSymbol] {
+			unproductive = append(unproductive, nt.NTSymbol)
+		}
+	}
+
+	return unproductive
+}
+
+// Unreachable returns the nonterminals that do not occur in any sentential
+// form derived from the root.
+func (gr *Grammar) Unreachable() []string {
+	reachable := map[string]bool{gr.Root: true}
+	queue := []string{gr.Root}
+
+	for len(queue) > 0 {
+		nt := queue[0]
+		queue = queue[1:]
+
+		for _, r := range gr.Rules {
+			if r.LSymbol != nt {
+				continue
+			}
+
+			for i := 0; i < len(r.RSymbol); i++ {
+				symbol := r.RSymbol[i : i+1]
+				if gr.TokenType(symbol) == NTerm && !reachable[symbol] {
+					reachable[symbol] = true
+					queue = append(queue, symbol)
+				}
+			}
+		}
+	}
+
+	var unreachable []string
+	for _, nt := range gr.NTokens {
+		if !reachable[nt.NTSymbol] {
+			unreachable = append(unreachable, nt.NTSymbol)
+		}
+	}
+
+	return unreachable
+}
+
+// LeftRecursive returns the nonterminals A with a derivation A =>+ Aα.
+func (gr *Grammar) LeftRecursive() []string {
+	// edges[A] holds the nonterminals that can start a sentential form
+	// derived from A in one step
+	edges := make(map[string][]string)
+	for _, r := range gr.Rules {
+		for i := 0; i < len(r.RSymbol); i++ {
+			symbol := r.RSymbol[i : i+1]
+			if gr.TokenType(symbol) == Term {
+				break
+			}
+
+			if !contains(edges[r.LSymbol], symbol) {
+				edges[r.LSymbol] = append(edges[r.LSymbol], symbol)
+			}
+
+			if !gr.nullable[symbol] {
+				break
+			}
+		}
+	}
+
+	var recursive []string
+	for _, nt := range gr.NTokens {
+		visited := make(map[string]bool)
+		queue := append([]string(nil), edges[nt.NTSymbol]...)
+
+		for len(queue) > 0 {
+			symbol := queue[0]
+			queue = queue[1:]
+
+			if symbol == nt.NTSymbol {
+				recursive = append(recursive, nt.NTSymbol)
+				break
+			}
+
+			if visited[symbol] {
+				continue
+			}
+			visited[symbol] = true
+			queue = append(queue, edges[symbol]...)
+		}
+	}
+
+	return recursive
+}
+
+func contains(set []string, s string) bool {
+	for i := range set {
+		if set[i] == s {
+			return true
+		}
+	}
+
+	return false
+}
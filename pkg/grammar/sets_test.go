@@ -0,0 +1,76 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFirstFollow(t *testing.T) {
+	// the expression grammar without left recursion of the dragon book
+	gr, err := Read(strings.NewReader(`
+E -> TR
+R -> +TR | ε
+T -> FY
+Y -> *FY | ε
+F -> (E) | a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := map[string]string{
+		"E":  "( a",
+		"R":  "+ " + Epsilon,
+		"T":  "( a",
+		"Y":  "* " + Epsilon,
+		"F":  "( a",
+		"RY": "+ * " + Epsilon,
+		"YF": "* ( a",
+		"":   Epsilon,
+	}
+	for symbols, want := range first {
+		if got := strings.Join(gr.First(symbols), " "); got != want {
+			t.Errorf("FIRST(%s) = %s, want %s", symbols, got, want)
+		}
+	}
+
+	follow := map[string]string{
+		"E": ") " + EndMarker,
+		"R": ") " + EndMarker,
+		"T": "+ ) " + EndMarker,
+		"Y": "+ ) " + EndMarker,
+		"F": "+ * ) " + EndMarker,
+	}
+	for nt, want := range follow {
+		if got := strings.Join(gr.Follow(nt), " "); got != want {
+			t.Errorf("FOLLOW(%s) = %s, want %s", nt, got, want)
+		}
+	}
+
+	if !gr.Nullable("RY") || gr.Nullable("RT") {
+		t.Errorf("Nullable(RY) = %v, Nullable(RT) = %v", gr.Nullable("RY"), gr.Nullable("RT"))
+	}
+}
+
+func TestSymbolChecks(t *testing.T) {
+	gr, err := Read(strings.NewReader(`
+S -> Aa | B
+A -> Ab | Cc
+B -> b
+C -> Cd
+D -> a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(gr.Unproductive(), " "); got != "A C" {
+		t.Errorf("unproductive %s, want A C", got)
+	}
+	if got := strings.Join(gr.Unreachable(), " "); got != "D" {
+		t.Errorf("unreachable %s, want D", got)
+	}
+	if got := strings.Join(gr.LeftRecursive(), " "); got != "A C" {
+		t.Errorf("left recursive %s, want A C", got)
+	}
+}
@@ -0,0 +1,186 @@
+package grammar
+
+import "fmt"
+
+// Origin maps the rules of a transformed grammar to the rules of the
+// grammar it was made from: rule i stands for the original rules Origin[i]
+// applied one after another, from the left side down.
+type Origin [][]int
+
+// Leftmost translates a leftmost derivation of the transformed grammar,
+// given by its rules in the order they are applied, to the original one.
+func (o Origin) Leftmost(rules []int) []int {
+	var orig []int
+	for _, r := range rules {
+		orig = append(orig, o[r]...)
+	}
+
+	return orig
+}
+
+// Rightmost translates the reductions of an LR parser for the transformed
+// grammar to reductions for the original one.
+func (o Origin) Rightmost(reductions []int) []int {
+	var orig []int
+	for _, r := range reductions {
+		for i := len(o[r]) - 1; i >= 0; i-- {
+			orig = append(orig, o[r][i])
+		}
+	}
+
+	return orig
+}
+
+// Compose returns the origin of a grammar transformed by o and then by
+// next.
+func (o Origin) Compose(next Origin) Origin {
+	composed := make(Origin, len(next))
+	for i := range next {
+		composed[i] = o.Leftmost(next[i])
+	}
+
+	return composed
+}
+
+// derive returns a grammar with the root of gr and the rules, keeping the
+// order of the symbols of gr that are still used. New nonterminals follow
+// in the order of their rules.
+func (gr *Grammar) derive(rules []Rule) (*Grammar, error) {
+	gs := GrammarSettings{Root: gr.Root, Rules: rules}
+
+	lhs := make(map[string]bool)
+	rhs := make(map[string]bool)
+	for _, r := range rules {
+		lhs[r.LSymbol] = true
+		for i := 0; i < len(r.RSymbol); i++ {
+			rhs[r.RSymbol[i:i+1]] = true
+		}
+	}
+
+	for _, nt := range gr.NTokens {
+		if lhs[nt.NTSymbol] {
+			gs.NTSymbols = append(gs.NTSymbols, nt.NTSymbol)
+		}
+	}
+	for _, r := range rules {
+		if gr.FindNToken(r.LSymbol) < 0 {
+			gs.NTSymbols = append(gs.NTSymbols, r.LSymbol)
+		}
+	}
+	for _, t := range gr.TTokens {
+		if rhs[t.TSymbol] {
+			gs.TSymbols = append(gs.TSymbols, t.TSymbol)
+		}
+	}
+
+	return New(gs)
+}
+
+func (gr *Grammar) isUnit(r Rule) bool {
+	return len(r.RSymbol) == 1 && gr.TokenType(r.RSymbol) == NTerm
+}
+
+// RemoveUnitRules returns an equivalent grammar without rules A -> B for
+// nonterminals A and B. Each such chain A -> B -> ... -> C followed by a
+// rule C -> α becomes a rule A -> α with the action and annotation of
+// C -> α; the attribute equations are not kept.
+func (gr *Grammar) RemoveUnitRules() (*Grammar, Origin, error) {
+	var rules []Rule
+	var origin Origin
+	seen := make(map[[2]string]bool)
+
+	for _, nt := range gr.NTokens {
+		// chains[B] is the shortest chain of unit rules from nt to B
+		chains := map[string][]int{nt.NTSymbol: nil}
+		queue := []string{nt.NTSymbol}
+
+		for len(queue) > 0 {
+			b := queue[0]
+			queue = queue[1:]
+
+			for _, i := range gr.NTokens[gr.FindNToken(b)].Alt {
+				r := gr.Rules[i]
+
+				if !gr.isUnit(r) {
+					key := [2]string{nt.NTSymbol, r.RSymbol}
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+
+					rules = append(rules, Rule{
+						LSymbol:    nt.NTSymbol,
+						RSymbol:    r.RSymbol,
+						Action:     r.Action,
+						Annotation: r.Annotation,
+					})
+					origin = append(origin, append(append([]int(nil), chains[b]...), i))
+					continue
+				}
+
+				if _, ok := chains[r.RSymbol]; !ok {
+					chains[r.RSymbol] = append(append([]int(nil), chains[b]...), i)
+					queue = append(queue, r.RSymbol)
+				}
+			}
+		}
+	}
+
+	newGrammar, err := gr.derive(rules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newGrammar, origin, nil
+}
+
+// RemoveUseless returns the grammar without the rules of the unproductive
+// and unreachable nonterminals and the rules using them.
+func (gr *Grammar) RemoveUseless() (*Grammar, Origin, error) {
+	unproductive := gr.Unproductive()
+	if contains(unproductive, gr.Root) {
+		return nil, nil, fmt.Errorf("the language of the grammar is empty")
+	}
+
+	keep := func(g *Grammar, useless []string) ([]Rule, []int) {
+		var rules []Rule
+		var kept []int
+
+	rules:
+		for i, r := range g.Rules {
+			if contains(useless, r.LSymbol) {
+				continue
+			}
+			for j := 0; j < len(r.RSymbol); j++ {
+				if contains(useless, r.RSymbol[j:j+1]) {
+					continue rules
+				}
+			}
+
+			rules = append(rules, r)
+			kept = append(kept, i)
+		}
+
+		return rules, kept
+	}
+
+	// unreachable symbols are found once the unproductive ones are gone
+	rules, kept := keep(gr, unproductive)
+	productive, err := gr.derive(rules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rules, reachable := keep(productive, productive.Unreachable())
+	newGrammar, err := productive.derive(rules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	origin := make(Origin, len(reachable))
+	for i := range reachable {
+		origin[i] = []int{kept[reachable[i]]}
+	}
+
+	return newGrammar, origin, nil
+}
@@ -0,0 +1,173 @@
+package grammar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readString(t *testing.T, s string) *Grammar {
+	gr, err := Read(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gr
+}
+
+// checkOrigin checks that the original rules of each rule form a chain of
+// unit rules ending in a rule with the same right side.
+func checkOrigin(t *testing.T, orig *Grammar, gr *Grammar, origin Origin) {
+	if len(origin) != len(gr.Rules) {
+		t.Fatalf("origin of %d rules for %d rules", len(origin), len(gr.Rules))
+	}
+
+	for i, r := range gr.Rules {
+		lhs := r.LSymbol
+		for j, o := range origin[i] {
+			if orig.Rules[o].LSymbol != lhs {
+				t.Errorf("rule %d: original rule %d does not expand %s", i, o, lhs)
+			}
+			lhs = orig.Rules[o].RSymbol
+
+			if j < len(origin[i])-1 && !orig.isUnit(orig.Rules[o]) {
+				t.Errorf("rule %d: original rule %d is not a unit rule", i, o)
+			}
+		}
+
+		if lhs != r.RSymbol {
+			t.Errorf("rule %d: original rules %v end in %s, want %s", i, origin[i], lhs, r.RSymbol)
+		}
+	}
+}
+
+func TestRemoveUnitRules(t *testing.T) {
+	orig := readString(t, `
+S -> E
+E -> E+T | T
+T -> T*F | F
+F -> (E) | a
+`)
+
+	gr, origin, err := orig.RemoveUnitRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range gr.Rules {
+		if gr.isUnit(r) {
+			t.Errorf("rule %d: %s -> %s is a unit rule", i, r.LSymbol, r.RSymbol)
+		}
+	}
+
+	if len(gr.Rules) != 13 {
+		t.Errorf("%d rules, want 13", len(gr.Rules))
+	}
+
+	checkOrigin(t, orig, gr, origin)
+
+	// S -> a is S -> E -> T -> F -> a
+	want := []int{0, 2, 4, 6}
+	for i, r := range gr.Rules {
+		if r.LSymbol == "S" && r.RSymbol == "a" && !reflect.DeepEqual(origin[i], want) {
+			t.Errorf("origin of S -> a is %v, want %v", origin[i], want)
+		}
+	}
+}
+
+func TestRemoveUseless(t *testing.T) {
+	orig := readString(t, `
+S -> aA | X | b
+A -> a
+X -> Xa
+Y -> b
+`)
+
+	gr, origin, err := orig.RemoveUseless()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rules []string
+	for _, r := range gr.Rules {
+		rules = append(rules, r.LSymbol+"->"+r.RSymbol)
+	}
+
+	if s := strings.Join(rules, " "); s != "S->aA S->b A->a" {
+		t.Errorf("rules %s, want S->aA S->b A->a", s)
+	}
+
+	if want := (Origin{{0}, {2}, {3}}); !reflect.DeepEqual(origin, want) {
+		t.Errorf("origin %v, want %v", origin, want)
+	}
+
+	if _, _, err := readString(t, "S -> aS\n").RemoveUseless(); err == nil {
+		t.Error("no error for an empty language")
+	}
+}
+
+// sentences returns the sentences of the grammar of at most n symbols for
+// an ε-free grammar.
+func sentences(gr *Grammar, n int) map[string]bool {
+	found := make(map[string]bool)
+	seen := map[string]bool{gr.Root: true}
+	queue := []string{gr.Root}
+
+	for len(queue) > 0 {
+		form := queue[0]
+		queue = queue[1:]
+
+		i := strings.IndexFunc(form, func(r rune) bool {
+			return gr.TokenType(string(r)) == NTerm
+		})
+		if i < 0 {
+			found[form] = true
+			continue
+		}
+
+		for _, a := range gr.NTokens[gr.FindNToken(form[i:i+1])].Alt {
+			next := form[:i] + gr.Rules[a].RSymbol + form[i+1:]
+			if len(next) <= n && !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return found
+}
+
+func TestToGNF(t *testing.T) {
+	for _, s := range []string{
+		"S -> E\nE -> E+T | T\nT -> T*F | F\nF -> (E) | a\n",
+		"S -> AB | b\nA -> Sa | a\nB -> Bb | c\n",
+	} {
+		orig := readString(t, s)
+
+		gr, steps, err := orig.ToGNF()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if steps[len(steps)-1].Grammar != gr {
+			t.Error("the last step is not the result")
+		}
+
+		for i, r := range gr.Rules {
+			for j := 0; j < len(r.RSymbol); j++ {
+				if (gr.TokenType(r.RSymbol[j:j+1]) == Term) != (j == 0) {
+					t.Errorf("rule %d: %s -> %s is not in Greibach normal form", i, r.LSymbol, r.RSymbol)
+					break
+				}
+			}
+		}
+
+		if want, got := sentences(orig, 7), sentences(gr, 7); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d sentences of length up to 7, want %d", len(got), len(want))
+		}
+	}
+
+	if _, _, err := readString(t, "S -> aS | ε\n").ToGNF(); err == nil {
+		t.Error("no error for a grammar with ε rules")
+	}
+}
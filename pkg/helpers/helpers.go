@@ -1,16 +1,19 @@
 package helpers
 
+// Unique returns the distinct elements of slice in the order of their first
+// occurrence.
 func Unique(slice []string) []string {
 	uniqMap := make(map[string]struct{})
 
-	for i := range slice {
-		uniqMap[slice[i]] = struct{}{}
-	}
-
 	uniqSlice := make([]string, 0)
 
-	for k, _ := range uniqMap {
-		uniqSlice = append(uniqSlice, k)
+	for i := range slice {
+		if _, ok := uniqMap[slice[i]]; ok {
+			continue
+		}
+
+		uniqMap[slice[i]] = struct{}{}
+		uniqSlice = append(uniqSlice, slice[i])
 	}
 
 	return uniqSlice
@@ -0,0 +1,179 @@
+// Package ir is a three-address code intermediate representation with a
+// translation scheme producing it from a small language of assignments,
+// if and while statements.
+package ir
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// Operators of jumps. A conditional jump goes to Result if Arg1 Op Arg2.
+const (
+	Goto   = "j"
+	Assign = ":="
+)
+
+// Quad is a quadruple: an operator, up to two arguments and a result,
+// which is a variable, a temporary or the target of a jump.
+type Quad struct {
+	Op     string
+	Arg1   string
+	Arg2   string
+	Result string
+}
+
+// Triple is an instruction whose result is referred to by its position,
+// written (n), instead of a temporary.
+type Triple struct {
+	Op   string
+	Arg1 string
+	Arg2 string
+}
+
+// Code is a list of quadruples. Jump targets are quadruple numbers, and a
+// jump to len(Quads) leaves the code.
+type Code struct {
+	Quads []Quad
+	temps int
+}
+
+// NextQuad returns the number of the next quadruple to be emitted.
+func (c *Code) NextQuad() int {
+	return len(c.Quads)
+}
+
+// Emit appends a quadruple and returns its number.
+func (c *Code) Emit(op, arg1, arg2, result string) int {
+	c.Quads = append(c.Quads, Quad{Op: op, Arg1: arg1, Arg2: arg2, Result: result})
+	return len(c.Quads) - 1
+}
+
+// NewTemp returns a new temporary t1, t2 and so on.
+func (c *Code) NewTemp() string {
+	c.temps++
+	return fmt.Sprintf("t%d", c.temps)
+}
+
+// Backpatch sets the target of the jumps in the list.
+func (c *Code) Backpatch(list []int, target int) {
+	for _, i := range list {
+		c.Quads[i].Result = strconv.Itoa(target)
+	}
+}
+
+// Merge returns the concatenation of jump lists.
+func Merge(lists ...[]int) []int {
+	var merged []int
+	for _, l := range lists {
+		merged = append(merged, l...)
+	}
+
+	return merged
+}
+
+func isJump(op string) bool {
+	return op == Goto || len(op) > 1 && op[0] == 'j'
+}
+
+// Triples returns the code as triples. A conditional jump becomes the
+// comparison followed by a jt triple jumping if it is true, temporaries
+// become references to the triples computing them.
+func (c *Code) Triples() []Triple {
+	start := make([]int, len(c.Quads)+1)
+	for i, q := range c.Quads {
+		start[i+1] = start[i] + 1
+		if isJump(q.Op) && q.Op != Goto {
+			start[i+1]++
+		}
+	}
+
+	target := func(s string) string {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n >= len(start) {
+			return s
+		}
+		return fmt.Sprintf("(%d)", start[n])
+	}
+
+	temps := make(map[string]string)
+	arg := func(s string) string {
+		if t, ok := temps[s]; ok {
+			return t
+		}
+		return s
+	}
+
+	var triples []Triple
+	for _, q := range c.Quads {
+		switch {
+		case q.Op == Goto:
+			triples = append(triples, Triple{Op: Goto, Arg1: target(q.Result)})
+		case isJump(q.Op):
+			triples = append(triples, Triple{Op: q.Op[1:], Arg1: arg(q.Arg1), Arg2: arg(q.Arg2)})
+			triples = append(triples, Triple{Op: "jt", Arg1: fmt.Sprintf("(%d)", len(triples)-1), Arg2: target(q.Result)})
+		case q.Op == Assign:
+			triples = append(triples, Triple{Op: Assign, Arg1: q.Result, Arg2: arg(q.Arg1)})
+		default:
+			temps[q.Result] = fmt.Sprintf("(%d)", len(triples))
+			triples = append(triples, Triple{Op: q.Op, Arg1: arg(q.Arg1), Arg2: arg(q.Arg2)})
+		}
+	}
+
+	return triples
+}
+
+// QuadsReport returns the quadruples as a table.
+func (c *Code) QuadsReport() report.Table {
+	table := report.Table{
+		Title:    "Quadruples",
+		Header:   []string{"#", "op", "arg1", "arg2", "result"},
+		Bordered: true,
+	}
+
+	for i, q := range c.Quads {
+		table.Rows = append(
+			table.Rows,
+			[]string{
+				fmt.Sprintf("%d", i),
+				q.Op,
+				q.Arg1,
+				q.Arg2,
+				q.Result,
+			},
+		)
+	}
+
+	return table
+}
+
+// TriplesReport returns the triples of the code as a table.
+func (c *Code) TriplesReport() report.Table {
+	table := report.Table{
+		Title:    "Triples",
+		Header:   []string{"#", "op", "arg1", "arg2"},
+		Bordered: true,
+	}
+
+	for i, t := range c.Triples() {
+		table.Rows = append(
+			table.Rows,
+			[]string{
+				fmt.Sprintf("(%d)", i),
+				t.Op,
+				t.Arg1,
+				t.Arg2,
+			},
+		)
+	}
+
+	return table
+}
+
+// Print prints the quadruples.
+func (c *Code) Print() {
+	report.Write(os.Stdout, report.Color, c.QuadsReport())
+}
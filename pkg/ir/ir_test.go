@@ -0,0 +1,78 @@
+package ir
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		program string
+		quads   []Quad
+	}{
+		{
+			program: "x = a + b*2",
+			quads: []Quad{
+				{"*", "b", "2", "t1"},
+				{"+", "a", "t1", "t2"},
+				{":=", "t2", "", "x"},
+			},
+		},
+		{
+			program: "f(a<b | c=1 & !(d>2)) x=1; y=2",
+			quads: []Quad{
+				{"j<", "a", "b", "6"},
+				{"j", "", "", "2"},
+				{"j=", "c", "1", "4"},
+				{"j", "", "", "7"},
+				{"j>", "d", "2", "7"},
+				{"j", "", "", "6"},
+				{":=", "1", "", "x"},
+				{":=", "2", "", "y"},
+			},
+		},
+		{
+			program: "w(x<3) {x=x+1}",
+			quads: []Quad{
+				{"j<", "x", "3", "2"},
+				{"j", "", "", "5"},
+				{"+", "x", "1", "t1"},
+				{":=", "t1", "", "x"},
+				{"j", "", "", "0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		code, err := Translate(tt.program)
+		if err != nil {
+			t.Errorf("%q: %v", tt.program, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(code.Quads, tt.quads) {
+			t.Errorf("%q: quadruples\n%v\nwant\n%v", tt.program, code.Quads, tt.quads)
+		}
+	}
+}
+
+func TestTriples(t *testing.T) {
+	code, err := Translate("w(x<3) x=x*2+1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Triple{
+		{"<", "x", "3"},
+		{"jt", "(0)", "(3)"},
+		{"j", "(7)", ""},
+		{"*", "x", "2"},
+		{"+", "(3)", "1"},
+		{":=", "x", "(4)"},
+		{"j", "(0)", ""},
+	}
+
+	if triples := code.Triples(); !reflect.DeepEqual(triples, want) {
+		t.Errorf("triples\n%v\nwant\n%v", triples, want)
+	}
+}
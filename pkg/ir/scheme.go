@@ -0,0 +1,175 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/lr1parser"
+)
+
+// Variables and constants of the language.
+const (
+	Variables = "abcdxyz"
+	Digits    = "0123456789"
+)
+
+// boolExpr is the value of a boolean expression: the jumps taken when it
+// is true and when it is false, to be backpatched.
+type boolExpr struct {
+	trueList  []int
+	falseList []int
+}
+
+// scheme holds the code emitted by the semantic actions of the grammar.
+type scheme struct {
+	code *Code
+}
+
+func (s *scheme) binary(args []grammar.Value) grammar.Value {
+	t := s.code.NewTemp()
+	s.code.Emit(args[1].(string), args[0].(string), args[2].(string), t)
+	return t
+}
+
+func (s *scheme) relation(args []grammar.Value) grammar.Value {
+	b := boolExpr{
+		trueList:  []int{s.code.NextQuad()},
+		falseList: []int{s.code.NextQuad() + 1},
+	}
+	s.code.Emit("j"+args[1].(string), args[0].(string), args[2].(string), "")
+	s.code.Emit(Goto, "", "", "")
+	return b
+}
+
+func nextList(v grammar.Value) []int {
+	if v == nil {
+		return nil
+	}
+	return v.([]int)
+}
+
+func second(args []grammar.Value) grammar.Value {
+	return args[1]
+}
+
+// settings returns the grammar of the language with the translation
+// scheme as semantic actions:
+//
+//	P -> L              program
+//	L -> L;MS | S       statements
+//	S -> v=E            assignment to a variable v
+//	S -> f(B)MS         if
+//	S -> wM(B)MS        while
+//	S -> {L}            block
+//	B -> B|MC | C       or
+//	C -> C&MD | D       and
+//	D -> !D | (B) | E<E | E>E | E=E
+//	E -> E+T | E-T | T
+//	T -> T*F | T/F | F
+//	F -> (E) | v | n    variables and digits
+//	M -> ε              marker of the next quadruple
+func (s *scheme) settings() grammar.GrammarSettings {
+	rules := []grammar.Rule{
+		{LSymbol: "P", RSymbol: "L", Action: func(args []grammar.Value) grammar.Value {
+			s.code.Backpatch(nextList(args[0]), s.code.NextQuad())
+			return nil
+		}},
+		{LSymbol: "L", RSymbol: "L;MS", Action: func(args []grammar.Value) grammar.Value {
+			s.code.Backpatch(nextList(args[0]), args[2].(int))
+			return args[3]
+		}},
+		{LSymbol: "L", RSymbol: "S"},
+		{LSymbol: "S", RSymbol: "f(B)MS", Action: func(args []grammar.Value) grammar.Value {
+			b := args[2].(boolExpr)
+			s.code.Backpatch(b.trueList, args[4].(int))
+			return Merge(b.falseList, nextList(args[5]))
+		}},
+		{LSymbol: "S", RSymbol: "wM(B)MS", Action: func(args []grammar.Value) grammar.Value {
+			b := args[3].(boolExpr)
+			s.code.Backpatch(nextList(args[6]), args[1].(int))
+			s.code.Backpatch(b.trueList, args[5].(int))
+			s.code.Emit(Goto, "", "", fmt.Sprintf("%d", args[1].(int)))
+			return b.falseList
+		}},
+		{LSymbol: "S", RSymbol: "{L}", Action: second},
+		{LSymbol: "B", RSymbol: "B|MC", Action: func(args []grammar.Value) grammar.Value {
+			b1, b2 := args[0].(boolExpr), args[3].(boolExpr)
+			s.code.Backpatch(b1.falseList, args[2].(int))
+			return boolExpr{trueList: Merge(b1.trueList, b2.trueList), falseList: b2.falseList}
+		}},
+		{LSymbol: "B", RSymbol: "C"},
+		{LSymbol: "C", RSymbol: "C&MD", Action: func(args []grammar.Value) grammar.Value {
+			b1, b2 := args[0].(boolExpr), args[3].(boolExpr)
+			s.code.Backpatch(b1.trueList, args[2].(int))
+			return boolExpr{trueList: b2.trueList, falseList: Merge(b1.falseList, b2.falseList)}
+		}},
+		{LSymbol: "C", RSymbol: "D"},
+		{LSymbol: "D", RSymbol: "!D", Action: func(args []grammar.Value) grammar.Value {
+			b := args[1].(boolExpr)
+			return boolExpr{trueList: b.falseList, falseList: b.trueList}
+		}},
+		{LSymbol: "D", RSymbol: "(B)", Action: second},
+		{LSymbol: "D", RSymbol: "E<E", Action: s.relation},
+		{LSymbol: "D", RSymbol: "E>E", Action: s.relation},
+		{LSymbol: "D", RSymbol: "E=E", Action: s.relation},
+		{LSymbol: "E", RSymbol: "E+T", Action: s.binary},
+		{LSymbol: "E", RSymbol: "E-T", Action: s.binary},
+		{LSymbol: "E", RSymbol: "T"},
+		{LSymbol: "T", RSymbol: "T*F", Action: s.binary},
+		{LSymbol: "T", RSymbol: "T/F", Action: s.binary},
+		{LSymbol: "T", RSymbol: "F"},
+		{LSymbol: "F", RSymbol: "(E)", Action: second},
+		{LSymbol: "M", RSymbol: "", Action: func(args []grammar.Value) grammar.Value {
+			return s.code.NextQuad()
+		}},
+	}
+
+	for _, v := range Variables {
+		rules = append(rules, grammar.Rule{LSymbol: "S", RSymbol: string(v) + "=E", Action: func(args []grammar.Value) grammar.Value {
+			s.code.Emit(Assign, args[2].(string), "", args[0].(string))
+			return nil
+		}})
+		rules = append(rules, grammar.Rule{LSymbol: "F", RSymbol: string(v)})
+	}
+	for _, d := range Digits {
+		rules = append(rules, grammar.Rule{LSymbol: "F", RSymbol: string(d)})
+	}
+
+	gs := grammar.GrammarSettings{
+		Root:      "P",
+		NTSymbols: []string{"P", "L", "S", "B", "C", "D", "E", "T", "F", "M"},
+		Rules:     rules,
+	}
+	for _, t := range ";fw(){}|&!<>=+-*/" + Variables + Digits {
+		gs.TSymbols = append(gs.TSymbols, string(t))
+	}
+
+	return gs
+}
+
+// Translate translates the program to quadruples. Whitespace is ignored.
+func Translate(program string) (*Code, error) {
+	s := &scheme{code: &Code{}}
+
+	gr, err := grammar.New(s.settings())
+	if err != nil {
+		return nil, err
+	}
+
+	program = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, program)
+
+	lr1p := lr1parser.NewLR1Parser(*gr, program)
+	lr1p.SetAlgorithm(lr1parser.LALR1)
+	if err := lr1p.Parse(); err != nil {
+		return nil, err
+	}
+
+	return s.code, nil
+}
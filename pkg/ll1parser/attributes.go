@@ -0,0 +1,118 @@
+package ll1parser
+
+import (
+	"fmt"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+// ParseAttributes parses the input top-down like Parse and evaluates the
+// attribute equations of the grammar while doing so. The root has the
+// inherited attributes given, and its synthesized attributes are returned.
+// The definitions must be L-attributed: the inherited attributes of a
+// symbol are computed before the symbol is parsed, from what is known at
+// that point.
+func (ll1p *LL1Parser) ParseAttributes(inherited map[string]grammar.Value) (map[string]grammar.Value, error) {
+	if err := ll1p.grammar.CheckLAttributed(); err != nil {
+		return nil, err
+	}
+
+	ll1p.production = make([]int, 0)
+	ll1p.steps = make([]Step, 0)
+	ll1p.inputIter = 0
+
+	if ll1p.table == nil {
+		ll1p.BuildTable()
+	}
+
+	attrs, err := ll1p.parseSymbol(ll1p.grammar.Root, inherited)
+	if err != nil {
+		return nil, err
+	}
+
+	if a := ll1p.lookahead(); a != grammar.EndMarker {
+		return nil, fmt.Errorf("unexpected symbol %q at position %d", a, ll1p.inputIter)
+	}
+
+	return attrs, nil
+}
+
+// parseSymbol parses the symbol with the inherited attributes given and
+// returns all its attributes.
+func (ll1p *LL1Parser) parseSymbol(x string, inherited map[string]grammar.Value) (map[string]grammar.Value, error) {
+	a := ll1p.lookahead()
+
+	if ll1p.grammar.TokenType(x) == grammar.Term {
+		if x != a {
+			return nil, fmt.Errorf("expected %q, got %q at position %d", x, a, ll1p.inputIter)
+		}
+		ll1p.inputIter++
+
+		return map[string]grammar.Value{grammar.TextAttr: a}, nil
+	}
+
+	r, ok := ll1p.rule(x, a)
+	if !ok {
+		return nil, fmt.Errorf("unexpected symbol %q at position %d", a, ll1p.inputIter)
+	}
+	ll1p.production = append(ll1p.production, r)
+
+	rule := ll1p.grammar.Rules[r]
+	env := make([]map[string]grammar.Value, len(rule.RSymbol)+1)
+	env[0] = make(map[string]grammar.Value)
+	for name, v := range inherited {
+		env[0][name] = v
+	}
+
+	done := make([]bool, len(rule.Equations))
+	for pos := 1; pos <= len(rule.RSymbol); pos++ {
+		env[pos] = make(map[string]grammar.Value)
+		evaluate(rule, env, done, pos)
+
+		attrs, err := ll1p.parseSymbol(rule.Symbol(pos), env[pos])
+		if err != nil {
+			return nil, err
+		}
+		for name, v := range attrs {
+			env[pos][name] = v
+		}
+	}
+
+	evaluate(rule, env, done, 0)
+
+	for i, eq := range rule.Equations {
+		if !done[i] {
+			return nil, fmt.Errorf("rule %d: %s cannot be evaluated", r, rule.AttrString(eq.Target))
+		}
+	}
+
+	return env[0], nil
+}
+
+// evaluate computes the equations of the rule for the symbol at the
+// position as long as their arguments are known.
+func evaluate(rule grammar.Rule, env []map[string]grammar.Value, done []bool, pos int) {
+	for progress := true; progress; {
+		progress = false
+
+	equations:
+		for i, eq := range rule.Equations {
+			if done[i] || eq.Target.Pos != pos {
+				continue
+			}
+
+			args := make([]grammar.Value, len(eq.Args))
+			for j, a := range eq.Args {
+				v, ok := env[a.Pos][a.Name]
+				if !ok {
+					continue equations
+				}
+				args[j] = v
+			}
+
+			env[pos][eq.Target.Name] = eq.Func(args)
+			done[i] = true
+			progress = true
+		}
+	}
+}
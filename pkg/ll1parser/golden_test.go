@@ -0,0 +1,22 @@
+package ll1parser
+
+import (
+	"testing"
+
+	"github.com/svkirillov/translator-labs/internal/goldentest"
+)
+
+func TestGolden(t *testing.T) {
+	goldentest.Run(t, goldentest.Dir, func(t *testing.T, c *goldentest.Case) map[string]string {
+		ll1p := NewLL1Parser(*c.Grammar, "")
+		ll1p.BuildTable()
+
+		results := goldentest.Results(c.Inputs, func(in string) ([]int, error) {
+			ll1p := NewLL1Parser(*c.Grammar, in)
+			err := ll1p.Parse()
+			return ll1p.Production(), err
+		})
+
+		return map[string]string{"ll1": goldentest.Text(ll1p.TableReport(), ll1p.ConflictsReport(), results)}
+	})
+}
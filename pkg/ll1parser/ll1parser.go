@@ -0,0 +1,306 @@
+package ll1parser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+type LL1Parser struct {
+	grammar    *grammar.Grammar
+	input      string
+	stack      []string
+	production []int
+	table      [][]int
+	conflicts  []Conflict
+	inputIter  int
+	steps      []Step
+}
+
+// Conflict is a cell of the parsing table with more than one rule.
+type Conflict struct {
+	NTSymbol string
+	TSymbol  string
+	Rules    []int
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("symbol %s, lookahead %s: rules %v", c.NTSymbol, c.TSymbol, c.Rules)
+}
+
+// Step is a single step of the LL(1) driver: the stack and the remaining
+// input before the action is taken. The stack is listed from bottom to top.
+type Step struct {
+	Stack  string
+	Input  string
+	Action string
+}
+
+func NewLL1Parser(gr grammar.Grammar, in string) LL1Parser {
+	return LL1Parser{
+		grammar:    &gr,
+		input:      in,
+		stack:      []string{gr.Root, grammar.EndMarker},
+		production: nil,
+		table:      nil,
+		conflicts:  nil,
+		inputIter:  0,
+		steps:      nil,
+	}
+}
+
+// setRule sets the rule of the nonterminal, numbered as in NTokens, and
+// the terminal ID.
+func (ll1p *LL1Parser) setRule(nt int, t int, rule int) {
+	old := ll1p.table[nt][t]
+	if old < 0 || old == rule {
+		ll1p.table[nt][t] = rule
+		return
+	}
+
+	ntSymbol := ll1p.grammar.NTokens[nt].NTSymbol
+	tSymbol := ll1p.grammar.Symbol(t)
+
+	for i := range ll1p.conflicts {
+		if ll1p.conflicts[i].NTSymbol == ntSymbol && ll1p.conflicts[i].TSymbol == tSymbol {
+			ll1p.conflicts[i].Rules = append(ll1p.conflicts[i].Rules, rule)
+			return
+		}
+	}
+
+	// the earlier rule stays in the table
+	ll1p.conflicts = append(
+		ll1p.conflicts,
+		Conflict{
+			NTSymbol: ntSymbol,
+			TSymbol:  tSymbol,
+			Rules:    []int{old, rule},
+		},
+	)
+}
+
+// BuildTable builds the parsing table. Of the conflicting rules the earlier
+// one is used, and the conflicts are reported by Conflicts.
+func (ll1p *LL1Parser) BuildTable() {
+	// a row of rules for each nonterminal, indexed by the terminal IDs
+	ll1p.table = make([][]int, len(ll1p.grammar.NTokens))
+	ll1p.conflicts = nil
+
+	for i := range ll1p.table {
+		ll1p.table[i] = make([]int, len(ll1p.grammar.TTokens)+1)
+		for j := range ll1p.table[i] {
+			ll1p.table[i][j] = -1
+		}
+	}
+
+	for i, r := range ll1p.grammar.Rules {
+		nt := ll1p.grammar.FindNToken(r.LSymbol)
+
+		for _, t := range ll1p.grammar.First(r.RSymbol) {
+			if t != grammar.Epsilon {
+				ll1p.setRule(nt, ll1p.grammar.ID(t), i)
+				continue
+			}
+
+			for _, f := range ll1p.grammar.Follow(r.LSymbol) {
+				ll1p.setRule(nt, ll1p.grammar.ID(f), i)
+			}
+		}
+	}
+}
+
+// rule returns the rule of the table for the nonterminal and the lookahead.
+func (ll1p *LL1Parser) rule(nt string, a string) (int, bool) {
+	t := ll1p.grammar.ID(a)
+	if t < 0 || ll1p.grammar.Kind(t) != grammar.Term {
+		return 0, false
+	}
+
+	r := ll1p.table[ll1p.grammar.FindNToken(nt)][t]
+	return r, r >= 0
+}
+
+// Conflicts returns the conflicts found by BuildTable.
+func (ll1p *LL1Parser) Conflicts() []Conflict {
+	return ll1p.conflicts
+}
+
+// TableReport returns the parsing table.
+func (ll1p *LL1Parser) TableReport() report.Table {
+	if ll1p.table == nil {
+		ll1p.BuildTable()
+	}
+
+	var terms []string
+	for t := 0; t <= len(ll1p.grammar.TTokens); t++ {
+		terms = append(terms, ll1p.grammar.Symbol(t))
+	}
+
+	table := report.Table{
+		Title:    "LL(1) table",
+		Header:   append([]string{"Symbol"}, terms...),
+		Bordered: true,
+	}
+
+	for i, nt := range ll1p.grammar.NTokens {
+		row := []string{nt.NTSymbol}
+		for _, r := range ll1p.table[i] {
+			var str string
+			if r >= 0 {
+				str = fmt.Sprintf("%d", r)
+			}
+			row = append(row, str)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
+
+// ConflictsReport returns the conflicts found by BuildTable.
+func (ll1p *LL1Parser) ConflictsReport() report.Table {
+	table := report.Table{
+		Title:  "Conflicts",
+		Header: []string{"Symbol", "Lookahead", "Rules"},
+	}
+
+	for _, c := range ll1p.conflicts {
+		rules := make([]string, len(c.Rules))
+		for i := range c.Rules {
+			rules[i] = fmt.Sprintf("%d", c.Rules[i])
+		}
+		table.Rows = append(table.Rows, []string{c.NTSymbol, c.TSymbol, strings.Join(rules, " ")})
+	}
+
+	return table
+}
+
+// PrintTable prints the parsing table.
+func (ll1p *LL1Parser) PrintTable() {
+	report.Write(os.Stdout, report.Color, ll1p.TableReport())
+}
+
+// Steps returns the steps taken by the last call to Parse.
+func (ll1p *LL1Parser) Steps() []Step {
+	return ll1p.steps
+}
+
+// StepsReport returns the steps taken by the last call to Parse.
+func (ll1p *LL1Parser) StepsReport() report.Table {
+	table := report.Table{
+		Title:  "Steps",
+		Header: []string{"Stack", "Input", "Action"},
+	}
+
+	for _, st := range ll1p.steps {
+		table.Rows = append(table.Rows, []string{st.Stack, st.Input, st.Action})
+	}
+
+	return table
+}
+
+// PrintSteps prints the steps taken by the last call to Parse.
+func (ll1p *LL1Parser) PrintSteps() {
+	report.Write(os.Stdout, report.Color, ll1p.StepsReport())
+}
+
+// Production returns the rules applied by the last call to Parse, which is
+// a leftmost derivation.
+func (ll1p *LL1Parser) Production() []int {
+	return ll1p.production
+}
+
+func (ll1p *LL1Parser) lookahead() string {
+	if ll1p.inputIter >= len(ll1p.input) {
+		return grammar.EndMarker
+	}
+
+	return ll1p.input[ll1p.inputIter : ll1p.inputIter+1]
+}
+
+func (ll1p *LL1Parser) addStep(action string) {
+	var stack string
+	for i := len(ll1p.stack) - 1; i >= 0; i-- {
+		stack += ll1p.stack[i]
+	}
+
+	input := grammar.EndMarker
+	if ll1p.inputIter < len(ll1p.input) {
+		input = ll1p.input[ll1p.inputIter:] + input
+	}
+
+	ll1p.steps = append(
+		ll1p.steps,
+		Step{
+			Stack:  stack,
+			Input:  input,
+			Action: action,
+		},
+	)
+}
+
+// Parse parses the input. It fails on a table with conflicts, since the
+// rule kept for a conflict may loop forever on a left recursion.
+func (ll1p *LL1Parser) Parse() error {
+	ll1p.stack = []string{ll1p.grammar.Root, grammar.EndMarker}
+	ll1p.inputIter = 0
+	ll1p.production = make([]int, 0)
+	ll1p.steps = make([]Step, 0)
+
+	if ll1p.table == nil {
+		ll1p.BuildTable()
+	}
+	if len(ll1p.conflicts) > 0 {
+		return fmt.Errorf("the LL(1) table has conflicts")
+	}
+
+	for {
+		x := ll1p.stack[0]
+		a := ll1p.lookahead()
+
+		switch {
+		case x == grammar.EndMarker:
+			if a != grammar.EndMarker {
+				ll1p.addStep("err")
+				return fmt.Errorf("unexpected symbol %q at position %d", a, ll1p.inputIter)
+			}
+
+			ll1p.addStep("acc")
+			return nil
+
+		case ll1p.grammar.TokenType(x) == grammar.Term:
+			if x != a {
+				ll1p.addStep("err")
+				return fmt.Errorf("expected %q, got %q at position %d", x, a, ll1p.inputIter)
+			}
+
+			ll1p.addStep(fmt.Sprintf("match %s", a))
+			ll1p.stack = ll1p.stack[1:]
+			ll1p.inputIter++
+
+		default:
+			r, ok := ll1p.rule(x, a)
+			if !ok {
+				ll1p.addStep("err")
+				return fmt.Errorf("unexpected symbol %q at position %d", a, ll1p.inputIter)
+			}
+
+			rule := ll1p.grammar.Rules[r]
+			rs := rule.RSymbol
+			if rs == "" {
+				rs = grammar.Epsilon
+			}
+			ll1p.addStep(fmt.Sprintf("%s -> %s", rule.LSymbol, rs))
+			ll1p.production = append(ll1p.production, r)
+
+			newStack := make([]string, len(rule.RSymbol))
+			for i := range rule.RSymbol {
+				newStack[i] = rule.RSymbol[i : i+1]
+			}
+			ll1p.stack = append(newStack, ll1p.stack[1:]...)
+		}
+	}
+}
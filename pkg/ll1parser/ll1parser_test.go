@@ -0,0 +1,119 @@
+package ll1parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+func read(t *testing.T, text string) *grammar.Grammar {
+	gr, err := grammar.Read(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gr
+}
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		grammar   string
+		conflicts string
+	}{
+		{
+			name: "LL(1)",
+			grammar: `
+E -> TR
+R -> +TR | ε
+T -> (E) | a
+`,
+			conflicts: "[]",
+		},
+		{
+			name:      "left recursive",
+			grammar:   "E -> E+a | a\n",
+			conflicts: "[symbol E, lookahead a: rules [0 1]]",
+		},
+		{
+			name:      "common prefix",
+			grammar:   "S -> ab | ac | d\n",
+			conflicts: "[symbol S, lookahead a: rules [0 1]]",
+		},
+	}
+
+	for _, tt := range tests {
+		ll1p := NewLL1Parser(*read(t, tt.grammar), "")
+		ll1p.BuildTable()
+
+		if got := fmt.Sprint(ll1p.Conflicts()); got != tt.conflicts {
+			t.Errorf("%s: conflicts %s, want %s", tt.name, got, tt.conflicts)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	gr := read(t, `
+E -> TR
+R -> +TR | ε
+T -> (E) | a
+`)
+
+	tests := []struct {
+		input      string
+		production string
+	}{
+		{"a", "[0 4 2]"},
+		{"a+a", "[0 4 1 4 2]"},
+		{"(a)+a", "[0 3 0 4 2 1 4 2]"},
+	}
+
+	for _, tt := range tests {
+		ll1p := NewLL1Parser(*gr, tt.input)
+		if err := ll1p.Parse(); err != nil {
+			t.Errorf("%q: %v", tt.input, err)
+			continue
+		}
+		if got := fmt.Sprint(ll1p.Production()); got != tt.production {
+			t.Errorf("%q: rules %s, want %s", tt.input, got, tt.production)
+		}
+	}
+
+	for _, in := range []string{"", "+a", "a+", "(a", "aa"} {
+		ll1p := NewLL1Parser(*gr, in)
+		if err := ll1p.Parse(); err == nil {
+			t.Errorf("%q: accepted with %v", in, ll1p.Production())
+		}
+	}
+}
+
+func TestParseTwice(t *testing.T) {
+	gr := read(t, `
+E -> TR
+R -> +TR | ε
+T -> (E) | a
+`)
+
+	ll1p := NewLL1Parser(*gr, "(a)+a")
+	for i := 0; i < 2; i++ {
+		if err := ll1p.Parse(); err != nil {
+			t.Fatalf("parse %d: %v", i+1, err)
+		}
+		if got, want := fmt.Sprint(ll1p.Production()), "[0 3 0 4 2 1 4 2]"; got != want {
+			t.Errorf("parse %d: rules %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestParseConflicts(t *testing.T) {
+	gr := read(t, `
+E -> E+a | a
+`)
+
+	ll1p := NewLL1Parser(*gr, "a+a")
+	if err := ll1p.Parse(); err == nil {
+		t.Errorf("accepted with conflicts %v", ll1p.Conflicts())
+	}
+}
@@ -0,0 +1,63 @@
+package lr1parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+// precedenceGrammar returns an expression grammar with the levels of two
+// left associative binary operators each, the kind of grammar whose LR(1)
+// automaton grows fast with its size.
+func precedenceGrammar(levels int) *grammar.Grammar {
+	const nts = "ABCDEFGHIJKLMNOPQRTUVWXYZ"
+	const ops = "+-*/%^&|<>=!~?:;,.@#bcdefghijklmnopqrstuvwxyz0123456789"
+
+	gs := grammar.GrammarSettings{
+		Root:     "S",
+		TSymbols: []string{"(", ")", "a"},
+	}
+	gs.NTSymbols = append(gs.NTSymbols, "S")
+	gs.Rules = append(gs.Rules, grammar.Rule{LSymbol: "S", RSymbol: nts[:1]})
+
+	for i := 0; i < levels; i++ {
+		nt, next := nts[i:i+1], nts[i+1:i+2]
+		gs.NTSymbols = append(gs.NTSymbols, nt)
+		for _, op := range []string{ops[2*i : 2*i+1], ops[2*i+1 : 2*i+2]} {
+			gs.TSymbols = append(gs.TSymbols, op)
+			gs.Rules = append(gs.Rules, grammar.Rule{LSymbol: nt, RSymbol: nt + op + next})
+		}
+		gs.Rules = append(gs.Rules, grammar.Rule{LSymbol: nt, RSymbol: next})
+	}
+
+	last := nts[levels : levels+1]
+	gs.NTSymbols = append(gs.NTSymbols, last)
+	gs.Rules = append(gs.Rules,
+		grammar.Rule{LSymbol: last, RSymbol: "(" + nts[:1] + ")"},
+		grammar.Rule{LSymbol: last, RSymbol: "a"},
+	)
+
+	gr, err := grammar.New(gs)
+	if err != nil {
+		panic(err)
+	}
+
+	return gr
+}
+
+func BenchmarkBuildTable(b *testing.B) {
+	for _, a := range []Algorithm{LR1, LALR1, Pager} {
+		for _, levels := range []int{4, 8, 16} {
+			gr := precedenceGrammar(levels)
+
+			b.Run(fmt.Sprintf("%v/levels=%d", a, levels), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					lr1p := NewLR1Parser(*gr, "")
+					lr1p.SetAlgorithm(a)
+					lr1p.BuildTable()
+				}
+			})
+		}
+	}
+}
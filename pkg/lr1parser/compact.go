@@ -0,0 +1,190 @@
+package lr1parser
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// entry is an action or a goto of the compact table: the state or the rule
+// shifted by two with the kind of the action in the low bits. Gotos are
+// stored as shifts of the nonterminals.
+type entry int32
+
+const errEntry = entry(err)
+
+func newEntry(act state) entry {
+	return entry(act.st)<<2 | entry(act.action)
+}
+
+func (e entry) state() state {
+	return state{action: int(e & 3), st: int(e >> 2)}
+}
+
+// compactTable is the ACTION and GOTO tables indexed by the symbol IDs of
+// the grammar, the terminals with the end marker first and then the
+// nonterminals. The most frequent reduction of a state is its default
+// action, which is taken instead of an error, and the rest of the rows are
+// packed into one comb vector: the entry of state s and symbol x is
+// entries[base[s]+x] if check holds s at the same index, and the default
+// of s otherwise.
+type compactTable struct {
+	defaults []entry
+	base     []int
+	entries  []entry
+	check    []int
+}
+
+// buildCompact packs the tables built by BuildTable.
+func (lr1p *LR1Parser) buildCompact() *compactTable {
+	terms := lr1p.endMarker() + 1
+
+	ct := &compactTable{
+		defaults: make([]entry, len(lr1p.actionTable)),
+		base:     make([]int, len(lr1p.actionTable)),
+	}
+
+	// the columns of each row left after taking out the default
+	rows := make([][]int, len(lr1p.actionTable))
+	values := make([][]entry, len(lr1p.actionTable))
+
+	for st := range lr1p.actionTable {
+		ct.defaults[st] = errEntry
+
+		count := make(map[int]int)
+		for _, act := range lr1p.actionTable[st] {
+			if act.action == reduce {
+				count[act.st]++
+			}
+		}
+		best := -1
+		for rule, n := range count {
+			if best < 0 || n > count[best] || n == count[best] && rule < best {
+				best = rule
+			}
+		}
+		if best >= 0 {
+			ct.defaults[st] = newEntry(state{action: reduce, st: best})
+		}
+
+		values[st] = make([]entry, lr1p.grammar.NumSymbols())
+		for id := range values[st] {
+			e := errEntry
+			if id < terms {
+				e = newEntry(lr1p.actionTable[st][id])
+			} else if k := lr1p.gotoTable[st][id-terms]; k >= 0 {
+				e = newEntry(state{action: shift, st: k})
+			}
+
+			values[st][id] = e
+			if e != errEntry && e != ct.defaults[st] {
+				rows[st] = append(rows[st], id)
+			}
+		}
+	}
+
+	// the fullest rows go first, which leaves the holes for the sparse ones
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(rows[order[i]]) > len(rows[order[j]])
+	})
+
+	for _, st := range order {
+		base := 0
+		for !ct.fits(rows[st], base) {
+			base++
+		}
+		ct.base[st] = base
+
+		for _, id := range rows[st] {
+			for len(ct.entries) <= base+id {
+				ct.entries = append(ct.entries, errEntry)
+				ct.check = append(ct.check, -1)
+			}
+			ct.entries[base+id] = values[st][id]
+			ct.check[base+id] = st
+		}
+	}
+
+	return ct
+}
+
+func (ct *compactTable) fits(row []int, base int) bool {
+	for _, id := range row {
+		if base+id < len(ct.check) && ct.check[base+id] >= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lookup returns the entry of the state and the symbol.
+func (ct *compactTable) lookup(st int, id int) entry {
+	i := ct.base[st] + id
+	if i < len(ct.check) && ct.check[i] == st {
+		return ct.entries[i]
+	}
+
+	return ct.defaults[st]
+}
+
+// size returns the number of cells of the compact table, counting the
+// base and the default of each state.
+func (ct *compactTable) size() int {
+	return len(ct.entries) + len(ct.check) + len(ct.base) + len(ct.defaults)
+}
+
+// SetCompact makes Parse use the compact table, which takes the default
+// reduction of a state on an unexpected symbol. The input is rejected at
+// the same symbol, but after the default reductions.
+func (lr1p *LR1Parser) SetCompact(compact bool) {
+	lr1p.compact = compact
+	lr1p.compactTable = nil
+}
+
+// action returns the action of the state on the lookahead ID, which is -1
+// for a symbol not in the grammar.
+func (lr1p *LR1Parser) action(st int, a int) state {
+	if a < 0 || a > lr1p.endMarker() {
+		return state{action: err}
+	}
+
+	if lr1p.compact {
+		return lr1p.compactTable.lookup(st, a).state()
+	}
+
+	return lr1p.actionTable[st][a]
+}
+
+// goTo returns the state after reducing to the nonterminal ID in the state.
+func (lr1p *LR1Parser) goTo(st int, nt int) int {
+	if lr1p.compact {
+		return lr1p.compactTable.lookup(st, nt).state().st
+	}
+
+	return lr1p.gotoTable[st][nt-lr1p.endMarker()-1]
+}
+
+// CompressionReport returns the size of the compact table against the
+// dense ACTION and GOTO tables, in cells.
+func (lr1p *LR1Parser) CompressionReport() report.Table {
+	if lr1p.actionTable == nil {
+		lr1p.BuildTable()
+	}
+
+	ct := lr1p.buildCompact()
+	dense := len(lr1p.actionTable) * lr1p.grammar.NumSymbols()
+
+	return report.Table{
+		Title: "Compression",
+		Rows: [][]string{{fmt.Sprintf(
+			"%d of %d cells, %.0f%%",
+			ct.size(), dense, 100*float64(ct.size())/float64(dense),
+		)}},
+	}
+}
@@ -0,0 +1,124 @@
+package lr1parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DOTOptions control the automaton written by WriteDOT.
+type DOTOptions struct {
+	// KernelOnly leaves out the items added by the closure.
+	KernelOnly bool
+	// HighlightConflicts fills the states with conflicts in red.
+	HighlightConflicts bool
+}
+
+// isKernel reports whether the item is a kernel item: its dot is not at
+// the start or it is the augmented item the automaton starts with.
+func isKernel(it item) bool {
+	return it.Position > 0 || it.RuleNum == augmented
+}
+
+// itemLabels returns the items of the state as "A -> α·β, a/b" with the
+// lookaheads of items with equal cores merged.
+func (lr1p *LR1Parser) itemLabels(st int, kernelOnly bool) []string {
+	var labels []string
+	var cores []item
+
+	for _, it := range lr1p.states[st] {
+		if kernelOnly && !isKernel(it) {
+			continue
+		}
+
+		found := false
+		for _, c := range cores {
+			if c.RuleNum == it.RuleNum && c.Position == it.Position {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		cores = append(cores, it)
+
+		var lookahead []string
+		for _, other := range lr1p.states[st] {
+			if other.RuleNum == it.RuleNum && other.Position == it.Position {
+				lookahead = append(lookahead, lr1p.grammar.Symbol(other.Lookahead))
+			}
+		}
+
+		rs := it.Rule.RSymbol
+		labels = append(
+			labels,
+			fmt.Sprintf("%s -> %s·%s, %s", it.Rule.LSymbol, rs[:it.Position], rs[it.Position:], strings.Join(lookahead, "/")),
+		)
+	}
+
+	return labels
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// WriteDOT writes the automaton of the table in the Graphviz DOT language:
+// a node per state listing its items and an edge per goto transition. The
+// accepting state has a double border and the states of MergedStates show
+// the number of LR(1) states merged into them.
+func (lr1p *LR1Parser) WriteDOT(w io.Writer, opts DOTOptions) error {
+	if lr1p.actionTable == nil {
+		lr1p.BuildTable()
+	}
+
+	merged := lr1p.MergedStates()
+
+	conflicting := make(map[int]bool)
+	for _, c := range lr1p.conflicts {
+		conflicting[c.State] = true
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "digraph %q {\n", lr1p.algorithm.String())
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=\"monospace\"];")
+
+	for st := range lr1p.states {
+		var label strings.Builder
+		fmt.Fprintf(&label, "%d", st)
+		if n := merged[st]; n > 0 {
+			fmt.Fprintf(&label, " (%d merged)", n)
+		}
+		label.WriteString("\\n")
+		for _, l := range lr1p.itemLabels(st, opts.KernelOnly) {
+			label.WriteString(dotEscape(l))
+			label.WriteString("\\l")
+		}
+
+		attrs := ""
+		if lr1p.actionTable[st][lr1p.endMarker()].action == accept {
+			attrs += ", peripheries=2"
+		}
+		if opts.HighlightConflicts && conflicting[st] {
+			attrs += ", style=filled, fillcolor=\"#ff9999\""
+		}
+
+		fmt.Fprintf(bw, "\t%d [label=\"%s\"%s];\n", st, label.String(), attrs)
+	}
+
+	for st := range lr1p.states {
+		for _, symbol := range lr1p.symbols() {
+			if k, ok := lr1p.trans[st][symbol]; ok {
+				fmt.Fprintf(bw, "\t%d -> %d [label=\"%s\"];\n", st, k, dotEscape(lr1p.grammar.Symbol(symbol)))
+			}
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
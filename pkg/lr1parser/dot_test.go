@@ -0,0 +1,97 @@
+package lr1parser
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+var danglingElse = `
+Z -> S
+S -> iEtS | iEtSeS | a
+E -> b
+`
+
+var dotNode = regexp.MustCompile(`^\t(\d+) \[label="((?:[^"\\]|\\.)*)"(.*)\];$`)
+
+// dotNodes returns the labels and the attributes after the label of the
+// nodes written by WriteDOT.
+func dotNodes(t *testing.T, lr1p *LR1Parser, opts DOTOptions) (labels, attrs []string) {
+	t.Helper()
+
+	var b strings.Builder
+	if err := lr1p.WriteDOT(&b, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(b.String(), "\n") {
+		if m := dotNode.FindStringSubmatch(line); m != nil {
+			labels = append(labels, m[2])
+			attrs = append(attrs, m[3])
+		}
+	}
+
+	if len(labels) != len(lr1p.states) {
+		t.Fatalf("%d nodes for %d states in\n%s", len(labels), len(lr1p.states), b.String())
+	}
+
+	return labels, attrs
+}
+
+func TestWriteDOT(t *testing.T) {
+	gr, err := grammar.Read(strings.NewReader(danglingElse))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lr1p := NewLR1Parser(*gr, "")
+	lr1p.BuildTable()
+
+	labels, attrs := dotNodes(t, &lr1p, DOTOptions{})
+	kernelLabels, _ := dotNodes(t, &lr1p, DOTOptions{KernelOnly: true})
+
+	// the closure of the start state adds the rules of S
+	if got, want := kernelLabels[0], `0\nZ' -> ·Z, ⊣\l`; got != want {
+		t.Errorf("kernel of state 0 = %q, want %q", got, want)
+	}
+	if !strings.Contains(labels[0], `S -> ·a, ⊣\l`) {
+		t.Errorf("state 0 = %q, want the closure items", labels[0])
+	}
+
+	for st := range labels {
+		if strings.Contains(kernelLabels[st], "-> ·") && st != 0 {
+			t.Errorf("state %d has a closure item with -kernel: %q", st, kernelLabels[st])
+		}
+		if !strings.HasPrefix(labels[st], kernelLabels[st]) {
+			t.Errorf("state %d: %q does not start with the kernel %q", st, labels[st], kernelLabels[st])
+		}
+
+		accept := strings.Contains(attrs[st], "peripheries=2")
+		if want := strings.Contains(labels[st], "Z' -> Z·"); accept != want {
+			t.Errorf("state %d %q: double border = %v, want %v", st, labels[st], accept, want)
+		}
+		if strings.Contains(attrs[st], "fillcolor") {
+			t.Errorf("state %d is filled without HighlightConflicts", st)
+		}
+	}
+
+	_, attrs = dotNodes(t, &lr1p, DOTOptions{HighlightConflicts: true})
+
+	filled := 0
+	for st := range attrs {
+		if !strings.Contains(attrs[st], "fillcolor") {
+			continue
+		}
+		filled++
+
+		// the shift of e and the reduction on e after then S
+		if !strings.Contains(labels[st], `S -> iEtS·, ⊣/e\l`) || !strings.Contains(labels[st], "S -> iEtS·eS") {
+			t.Errorf("state %d %q is filled, want the state of the dangling else", st, labels[st])
+		}
+	}
+	if filled != len(lr1p.Conflicts()) || filled == 0 {
+		t.Errorf("%d states filled for conflicts %v", filled, lr1p.Conflicts())
+	}
+}
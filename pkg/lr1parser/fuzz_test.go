@@ -0,0 +1,88 @@
+package lr1parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+var fuzzGrammars = []grammar.GrammarSettings{
+	{
+		Root:      "S",
+		TSymbols:  []string{"+", "*", "(", ")", "a"},
+		NTSymbols: []string{"S", "E", "T", "F"},
+		Rules: []grammar.Rule{
+			{LSymbol: "S", RSymbol: "E"},
+			{LSymbol: "E", RSymbol: "E+T"},
+			{LSymbol: "E", RSymbol: "T"},
+			{LSymbol: "T", RSymbol: "T*F"},
+			{LSymbol: "T", RSymbol: "F"},
+			{LSymbol: "F", RSymbol: "(E)"},
+			{LSymbol: "F", RSymbol: "a"},
+		},
+	},
+	{
+		Root:      "S",
+		TSymbols:  []string{"c", "d"},
+		NTSymbols: []string{"S", "E", "C"},
+		Rules: []grammar.Rule{
+			{LSymbol: "S", RSymbol: "E"},
+			{LSymbol: "E", RSymbol: "CC"},
+			{LSymbol: "C", RSymbol: "cC"},
+			{LSymbol: "C", RSymbol: "d"},
+		},
+	},
+}
+
+// FuzzParse checks that parsing never panics and that the LR(1), LALR(1),
+// SLR(1) and Pager tables of the grammars, which have no conflicts, give
+// the same result.
+func FuzzParse(f *testing.F) {
+	for _, in := range []string{"", "a", "a+a*a", "(a+a)*a", "a+", "$", "a$a", "cdd", "ccdcd", "dd$"} {
+		f.Add(in)
+	}
+
+	algorithms := []Algorithm{LR1, LALR1, SLR1, Pager}
+
+	// the tables are built once for all the inputs
+	var parsers [][]*LR1Parser
+	for _, gs := range fuzzGrammars {
+		gr, err := grammar.New(gs)
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		var ps []*LR1Parser
+		for _, a := range algorithms {
+			lr1p := NewLR1Parser(*gr, "")
+			lr1p.SetAlgorithm(a)
+			lr1p.BuildTable()
+			ps = append(ps, &lr1p)
+		}
+		parsers = append(parsers, ps)
+	}
+
+	f.Fuzz(func(t *testing.T, in string) {
+		for _, ps := range parsers {
+			var want string
+
+			for i, lr1p := range ps {
+				a := algorithms[i]
+
+				// LALR(1) and SLR(1) may reduce more before finding an error
+				lr1p.SetInput(in)
+				got := "rejected"
+				if err := lr1p.Parse(); err == nil {
+					got = fmt.Sprint(lr1p.Production())
+				}
+
+				if a == LR1 {
+					want = got
+				} else if got != want {
+					t.Errorf("%q: %v gives %s, LR(1) gives %s", in, a, got, want)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package lr1parser
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"text/template"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+var goTemplate = template.Must(template.New("parser").Funcs(template.FuncMap{
+	"rhs": func(symbols string) string {
+		if symbols == "" {
+			return grammar.Epsilon
+		}
+		return symbols
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+}).Parse(`// Code generated by translator generate; DO NOT EDIT.
+
+// Package {{.Package}} is an {{.Algorithm}} parser for the grammar
+//
+{{- range $i, $r := .Rules}}
+//	{{$i}}: {{$r.LSymbol}} -> {{rhs $r.RSymbol}}
+{{- end}}
+package {{.Package}}
+
+import "fmt"
+
+const (
+	accept = iota
+	shift
+	reduce
+	errAction
+)
+
+// rules holds the left side and the length of each rule.
+var rules = []struct {
+	lhs int
+	n   int
+}{
+{{- range .Rules}}
+	{ {{- call $.ID .LSymbol}}, {{len .RSymbol -}} },
+{{- end}}
+}
+
+// terminals holds the number of the terminal of each input byte plus one,
+// so the bytes that are not terminals hold zero.
+var terminals = [256]int{
+{{- range $i, $t := .Terminals}}
+	{{printf "%q" (index $t 0)}}: {{inc $i}},
+{{- end}}
+}
+
+// endMarker is the number of the end marker, after the terminals.
+const endMarker = {{len .Terminals}}
+
+// The ACTION and GOTO tables are packed into a comb vector. An entry is a
+// state or a rule shifted by two with the action in the low bits, gotos
+// are shifts. The entry of state s and symbol x is entries[base[s]+x] if
+// check holds s at the same index, and defaults[s] otherwise.
+var (
+	defaults = []int32{ {{- range .Defaults}}{{.}}, {{end -}} }
+	base     = []int{ {{- range .Base}}{{.}}, {{end -}} }
+	entries  = []int32{ {{- range .Entries}}{{.}}, {{end -}} }
+	check    = []int{ {{- range .Check}}{{.}}, {{end -}} }
+)
+
+func lookup(st int, x int) int32 {
+	if i := base[st] + x; i < len(check) && check[i] == st {
+		return entries[i]
+	}
+	return defaults[st]
+}
+
+// Parse parses the input and returns the rules of the reductions made,
+// which is a rightmost derivation in reverse.
+func Parse(input string) ([]int, error) {
+	stack := []int{0}
+	var production []int
+
+	for i := 0; ; {
+		x := endMarker
+		if i < len(input) {
+			x = terminals[input[i]] - 1
+		}
+
+		e := int32(errAction)
+		if x >= 0 {
+			e = lookup(stack[len(stack)-1], x)
+		}
+
+		switch e & 3 {
+		case shift:
+			stack = append(stack, int(e>>2))
+			i++
+		case reduce:
+			r := rules[e>>2]
+			stack = stack[:len(stack)-r.n]
+			stack = append(stack, int(lookup(stack[len(stack)-1], r.lhs)>>2))
+			production = append(production, int(e>>2))
+		case accept:
+			return production, nil
+		default:
+			a := "{{.EndMarker}}"
+			if i < len(input) {
+				a = input[i : i+1]
+			}
+			return nil, fmt.Errorf("unexpected symbol %q at position %d", a, i)
+		}
+	}
+}
+`))
+
+// WriteGo writes the source of a Go package with the given name that parses
+// the grammar with the parser's table, packed as the compact table. The
+// conflicts of the table are resolved as BuildTable does. The terminals
+// must be single bytes, which the generated parser indexes them by.
+func (lr1p *LR1Parser) WriteGo(w io.Writer, pkg string) error {
+	if lr1p.actionTable == nil {
+		lr1p.BuildTable()
+	}
+
+	// the generated parser indexes its terminals by the input bytes
+	for _, tt := range lr1p.grammar.TTokens {
+		if len(tt.TSymbol) != 1 {
+			return fmt.Errorf("terminal %q is not a single byte", tt.TSymbol)
+		}
+	}
+
+	ct := lr1p.buildCompact()
+
+	data := struct {
+		Package   string
+		Algorithm string
+		EndMarker string
+		Rules     []grammar.Rule
+		ID        func(string) int
+		Terminals []string
+		Defaults  []entry
+		Base      []int
+		Entries   []entry
+		Check     []int
+	}{
+		Package:   pkg,
+		Algorithm: lr1p.algorithm.String(),
+		EndMarker: grammar.EndMarker,
+		Rules:     lr1p.grammar.Rules,
+		ID:        lr1p.grammar.ID,
+		Terminals: lr1p.terminals()[:lr1p.endMarker()],
+		Defaults:  ct.defaults,
+		Base:      ct.base,
+		Entries:   ct.entries,
+		Check:     ct.check,
+	}
+
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated code: %v", err)
+	}
+
+	_, err = w.Write(src)
+	return err
+}
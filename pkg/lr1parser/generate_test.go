@@ -0,0 +1,144 @@
+package lr1parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/internal/goldentest"
+)
+
+type generatedResult struct {
+	Production []int
+	Err        string
+}
+
+// TestWriteGo generates a parser for each grammar of the corpus, builds
+// them into one program that parses the inputs of the corpus and compares
+// its results with the driver using the compact table.
+func TestWriteGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated parsers")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go tool")
+	}
+
+	cases, err := goldentest.Cases(goldentest.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bytes that are not terminals of any grammar
+	for _, c := range cases {
+		c.Inputs = append(c.Inputs, "", "?", "\xff")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module generated\n\ngo 1.18\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var imports, calls strings.Builder
+	for i, c := range cases {
+		pkg := fmt.Sprintf("p%d", i)
+		if err := os.Mkdir(filepath.Join(dir, pkg), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Create(filepath.Join(dir, pkg, "parser.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lr1p := NewLR1Parser(*c.Grammar, "")
+		if err := lr1p.WriteGo(f, pkg); err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintf(&imports, "\t%q\n", "generated/"+pkg)
+		fmt.Fprintf(&calls, "\tresults = append(results, parse(%s.Parse, %#v))\n", pkg, c.Inputs)
+	}
+
+	main := fmt.Sprintf(`package main
+
+import (
+	"encoding/json"
+	"os"
+
+%s)
+
+type result struct {
+	Production []int
+	Err        string
+}
+
+func parse(p func(string) ([]int, error), inputs []string) []result {
+	var rs []result
+	for _, in := range inputs {
+		production, err := p(in)
+		r := result{Production: production}
+		if err != nil {
+			r.Err = err.Error()
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+func main() {
+	var results [][]result
+%s
+	json.NewEncoder(os.Stdout).Encode(results)
+}
+`, imports.String(), calls.String())
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(goTool, "run", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			t.Fatalf("%v\n%s", err, ee.Stderr)
+		}
+		t.Fatal(err)
+	}
+
+	var results [][]generatedResult
+	if err := json.Unmarshal(out, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(cases) {
+		t.Fatalf("got results of %d grammars, want %d", len(results), len(cases))
+	}
+
+	for i, c := range cases {
+		lr1p := NewLR1Parser(*c.Grammar, "")
+		lr1p.SetCompact(true)
+		lr1p.BuildTable()
+
+		for j, in := range c.Inputs {
+			lr1p.SetInput(in)
+			var want generatedResult
+			if err := lr1p.Parse(); err != nil {
+				want.Err = err.Error()
+			} else {
+				want.Production = lr1p.Production()
+			}
+
+			got := results[i][j]
+			if got.Err != want.Err || fmt.Sprint(got.Production) != fmt.Sprint(want.Production) {
+				t.Errorf("%s: %q: generated %v %q, driver %v %q", c.Name, in, got.Production, got.Err, want.Production, want.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package lr1parser
+
+import (
+	"testing"
+
+	"github.com/svkirillov/translator-labs/internal/goldentest"
+)
+
+func TestGolden(t *testing.T) {
+	goldentest.Run(t, goldentest.Dir, func(t *testing.T, c *goldentest.Case) map[string]string {
+		out := make(map[string]string)
+
+		names := map[Algorithm]string{LR1: "lr1", LALR1: "lalr", SLR1: "slr", Pager: "pager"}
+
+		for a, name := range names {
+			lr1p := NewLR1Parser(*c.Grammar, "")
+			lr1p.SetAlgorithm(a)
+			lr1p.BuildTable()
+
+			results := goldentest.Results(c.Inputs, func(in string) ([]int, error) {
+				lr1p.SetInput(in)
+				err := lr1p.Parse()
+				return lr1p.Production(), err
+			})
+
+			out[name] = goldentest.Text(lr1p.TableReport(), lr1p.ConflictsReport(), results)
+		}
+
+		return out
+	})
+}
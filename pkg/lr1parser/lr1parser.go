@@ -3,11 +3,12 @@ package lr1parser
 import (
 	"fmt"
 	"os"
-
-	"github.com/olekukonko/tablewriter"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/svkirillov/translator-labs/pkg/grammar"
-	"github.com/svkirillov/translator-labs/pkg/helpers"
+	"github.com/svkirillov/translator-labs/pkg/report"
 )
 
 const (
@@ -19,21 +20,116 @@ const (
 
 type LR1Parser struct {
 	grammar     *grammar.Grammar
+	algorithm   Algorithm
 	input       string
 	stateStack  []int
+	symbolStack []string
+	valueStack  []grammar.Value
 	production  []int
-	actionTable []map[string]state
-	gotoTable   []map[string]int
+	result      grammar.Value
+	actionTable [][]state
+	gotoTable   [][]int
+	states      [][]item
+	trans       []map[int]int
 	inputIter   int
+	steps       []Step
+	conflicts   []Conflict
+	tails       [][]tail
+	merged      []int // LR(1) states merged into each state by Pager
+
+	compact      bool
+	compactTable *compactTable
+}
+
+// Algorithm is the way the parser builds its table.
+type Algorithm int
+
+const (
+	LR1 Algorithm = iota
+	LALR1
+	SLR1
+	// Pager is LR(1) with the states of equal cores merged where Pager's
+	// weak compatibility allows, which keeps the LR(1) power with about
+	// as many states as LALR(1).
+	Pager
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case LR1:
+		return "LR(1)"
+	case LALR1:
+		return "LALR(1)"
+	case SLR1:
+		return "SLR(1)"
+	case Pager:
+		return "Pager LR(1)"
+	default:
+		return "unknown algorithm"
+	}
+}
+
+var algorithmNames = map[string]Algorithm{
+	"lr1":   LR1,
+	"lalr":  LALR1,
+	"slr":   SLR1,
+	"pager": Pager,
+}
 
-	printer *tablewriter.Table
+// ParseAlgorithm returns the algorithm of the name: lr1, lalr, slr or
+// pager.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	a, ok := algorithmNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown algorithm %q", name)
+	}
+
+	return a, nil
+}
+
+// Conflict is a cell of the ACTION table with more than one action.
+type Conflict struct {
+	State   int
+	Symbol  string
+	Actions []string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("state %d, symbol %s: %s", c.State, c.Symbol, strings.Join(c.Actions, "/"))
+}
+
+// Step is a single step of the LR(1) driver: the stacks and the remaining
+// input before the action is taken. Stacks are listed from bottom to top.
+type Step struct {
+	States  []int
+	Symbols []string
+	Input   string
+	Action  string
 }
 
 type item struct {
 	Rule      grammar.Rule
 	RuleNum   int
 	Position  int
-	Lookahead string
+	Lookahead int
+}
+
+// augmented is the number of the rule S' -> S added for the root S, which
+// the automaton starts with and accepts by.
+const augmented = -1
+
+// augmentedRule returns the rule S' -> S for the root S.
+func (lr1p *LR1Parser) augmentedRule() grammar.Rule {
+	return grammar.Rule{LSymbol: lr1p.grammar.Root + "'", RSymbol: lr1p.grammar.Root}
+}
+
+// right returns the IDs of the right side of the item's rule.
+func (lr1p *LR1Parser) right(it *item) []int {
+	if it.RuleNum == augmented {
+		return []int{lr1p.grammar.ID(lr1p.grammar.Root)}
+	}
+
+	return lr1p.grammar.RightIDs(it.RuleNum)
 }
 
 type state struct {
@@ -42,23 +138,38 @@ type state struct {
 }
 
 func NewLR1Parser(gr grammar.Grammar, in string) LR1Parser {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetRowLine(true)
-
 	return LR1Parser{
 		grammar:     &gr,
 		input:       in,
 		stateStack:  []int{0},
+		symbolStack: nil,
 		production:  nil,
 		actionTable: nil,
 		gotoTable:   nil,
 		inputIter:   0,
-		printer:     table,
+		steps:       nil,
 	}
 }
 
+// SetAlgorithm sets the algorithm BuildTable uses. The default is LR1.
+func (lr1p *LR1Parser) SetAlgorithm(a Algorithm) {
+	lr1p.algorithm = a
+	lr1p.compactTable = nil
+	lr1p.actionTable = nil
+	lr1p.gotoTable = nil
+}
+
+// SetInput sets the input of the next call to Parse, so the table can be
+// used for more than one input.
+func (lr1p *LR1Parser) SetInput(in string) {
+	lr1p.input = in
+}
+
+// Steps returns the steps taken by the last call to Parse.
+func (lr1p *LR1Parser) Steps() []Step {
+	return lr1p.steps
+}
+
 func (lr1p *LR1Parser) stackPush(t int) {
 	old := lr1p.stateStack
 	lr1p.stateStack = make([]int, 1)
@@ -70,359 +181,674 @@ func (lr1p *LR1Parser) stackPop(n int) {
 	lr1p.stateStack = lr1p.stateStack[n:]
 }
 
-func (lr1p *LR1Parser) first_(token string) []string {
-	if token == "$" {
-		return []string{token}
-	}
-	if lr1p.grammar.TokenType(token) == grammar.Term {
-		return []string{token}
-	}
+func (lr1p *LR1Parser) symbolPush(s string) {
+	old := lr1p.symbolStack
+	lr1p.symbolStack = make([]string, 1)
+	lr1p.symbolStack[0] = s
+	lr1p.symbolStack = append(lr1p.symbolStack, old...)
+}
 
-	f := make([]string, 0)
+func (lr1p *LR1Parser) symbolPop(n int) {
+	lr1p.symbolStack = lr1p.symbolStack[n:]
+}
 
-	for i := 0; i < len(lr1p.grammar.Rules); i++ {
-		if lr1p.grammar.Rules[i].LSymbol == token && lr1p.grammar.Rules[i].RSymbol[0:1] != token {
-			fn := lr1p.first_(lr1p.grammar.Rules[i].RSymbol[0:1])
-			f = append(f, fn...)
-		}
+func (lr1p *LR1Parser) valuePush(v grammar.Value) {
+	old := lr1p.valueStack
+	lr1p.valueStack = make([]grammar.Value, 1)
+	lr1p.valueStack[0] = v
+	lr1p.valueStack = append(lr1p.valueStack, old...)
+}
+
+// valuePop pops n values and returns them from bottom to top.
+func (lr1p *LR1Parser) valuePop(n int) []grammar.Value {
+	args := make([]grammar.Value, n)
+	for i := 0; i < n; i++ {
+		args[n-1-i] = lr1p.valueStack[i]
 	}
+	lr1p.valueStack = lr1p.valueStack[n:]
 
-	return helpers.Unique(f)
+	return args
 }
 
-func (lr1p *LR1Parser) first(tokens string) []string {
-	f := make([]string, 0)
+// reduceValue pops the values of the rule's right side and returns the
+// value of its left side, computed by the rule's action. A rule without
+// an action passes on the value of its first symbol.
+func (lr1p *LR1Parser) reduceValue(rule grammar.Rule) grammar.Value {
+	args := lr1p.valuePop(len(rule.RSymbol))
 
-	if tokens == "$" {
-		return []string{tokens}
+	if rule.Action != nil {
+		return rule.Action(args)
+	}
+	if len(args) > 0 {
+		return args[0]
 	}
 
-	if string(tokens[len(tokens)-1]) == "$" {
-		tokens = tokens[:len(tokens)-1]
+	return nil
+}
+
+func (lr1p *LR1Parser) addStep(act state) {
+	st := Step{
+		States:  make([]int, len(lr1p.stateStack)),
+		Symbols: make([]string, len(lr1p.symbolStack)),
+		Input:   lr1p.input[lr1p.inputIter:] + grammar.EndMarker,
+		Action:  actionString(act),
 	}
 
-	for i := 0; i < len(tokens); i++ {
-		fn := lr1p.first_(tokens[i : i+1])
-		if len(fn) != 0 {
-			f = append(f, fn...)
-		}
+	for i := range lr1p.stateStack {
+		st.States[len(lr1p.stateStack)-1-i] = lr1p.stateStack[i]
+	}
+	for i := range lr1p.symbolStack {
+		st.Symbols[len(lr1p.symbolStack)-1-i] = lr1p.symbolStack[i]
+	}
+
+	lr1p.steps = append(lr1p.steps, st)
+}
+
+func actionString(act state) string {
+	switch act.action {
+	case accept:
+		return "acc"
+	case shift:
+		return fmt.Sprintf("s%d", act.st)
+	case reduce:
+		return fmt.Sprintf("r%d", act.st)
+	default:
+		return "err"
 	}
+}
 
-	return helpers.Unique(f)
+// itemKey identifies an item of a state.
+type itemKey struct {
+	rule      int
+	position  int
+	lookahead int
 }
 
-func (lr1p *LR1Parser) closure(items []item) []item {
-	it := items[:]
-	currentLen := len(it)
-	oldLen := 0
+func (it *item) key() itemKey {
+	return itemKey{it.RuleNum, it.Position, it.Lookahead}
+}
 
-	for {
-		for i := oldLen; i < currentLen; i++ {
-			position := it[i].Position
-			if position >= len(it[i].Rule.RSymbol) {
-				continue
-			}
+// tail is the FIRST set of the symbols after the one following a dot,
+// without ε, and whether they are nullable.
+type tail struct {
+	first    []int
+	nullable bool
+}
 
-			token := it[i].Rule.RSymbol[position : position+1]
-			if lr1p.grammar.TokenType(token) == grammar.Term {
-				continue
-			}
+// computeTails computes the tail of each dot position of the rules once,
+// since the closures need it for every item they add.
+func (lr1p *LR1Parser) computeTails() {
+	lr1p.tails = make([][]tail, len(lr1p.grammar.Rules))
 
-			tokenIndex := lr1p.grammar.FindNToken(token)
+	for i, r := range lr1p.grammar.Rules {
+		lr1p.tails[i] = make([]tail, len(r.RSymbol))
 
-			tail := ""
-			if position+1 >= len(it[i].Rule.RSymbol) {
-				tail = it[i].Lookahead
-			} else {
-				tail = it[i].Rule.RSymbol[position+1:] + it[i].Lookahead
-			}
-			f := lr1p.first(tail)
-
-			for j := range lr1p.grammar.NTokens[tokenIndex].Alt {
-				ruleNum := lr1p.grammar.NTokens[tokenIndex].Alt[j]
-				for k := range f {
-					item := item{
-						Rule:      lr1p.grammar.Rules[ruleNum],
-						RuleNum:   ruleNum,
-						Position:  0,
-						Lookahead: f[k],
-					}
-					if !checkItemIn(&item, it) {
-						it = append(
-							it,
-							item,
-						)
-					}
+		for pos := range r.RSymbol {
+			rest := r.RSymbol[pos+1:]
+
+			var first []int
+			for _, t := range lr1p.grammar.First(rest) {
+				if t != grammar.Epsilon {
+					first = append(first, lr1p.grammar.ID(t))
 				}
 			}
-		}
 
-		if currentLen == len(it) {
-			break
-		} else {
-			oldLen = currentLen
-			currentLen = len(it)
+			lr1p.tails[i][pos] = tail{first: first, nullable: lr1p.grammar.Nullable(rest)}
 		}
 	}
+}
+
+// tail returns the tail of the item, whose dot is before a symbol.
+func (lr1p *LR1Parser) tail(it *item) tail {
+	if it.RuleNum == augmented {
+		return tail{nullable: true}
+	}
 
-	return it
+	return lr1p.tails[it.RuleNum][it.Position]
 }
 
-func (lr1p *LR1Parser) goTo(items []item, token string) []item {
-	j := make([]item, 0)
+func (lr1p *LR1Parser) closure(kernel []item) []item {
+	it := kernel
+	seen := make(map[itemKey]bool, len(it))
+	for i := range it {
+		seen[it[i].key()] = true
+	}
 
-	for i := range items {
-		position := items[i].Position
+	for i := 0; i < len(it); i++ {
+		position := it[i].Position
+		right := lr1p.right(&it[i])
+		if position >= len(right) {
+			continue
+		}
 
-		if position+1 > len(items[i].Rule.RSymbol) {
+		tokenIndex := lr1p.grammar.NTokenIndex(right[position])
+		if tokenIndex < 0 {
 			continue
 		}
 
-		if items[i].Rule.RSymbol[position:position+1] == token {
-			j = append(
-				j,
-				item{
-					Rule:      items[i].Rule,
-					RuleNum:   items[i].RuleNum,
-					Position:  items[i].Position + 1,
-					Lookahead: items[i].Lookahead,
-				},
-			)
+		t := lr1p.tail(&it[i])
+		lookahead := t.first
+		if t.nullable {
+			lookahead = append(lookahead[:len(lookahead):len(lookahead)], it[i].Lookahead)
 		}
+
+		for _, ruleNum := range lr1p.grammar.NTokens[tokenIndex].Alt {
+			for _, la := range lookahead {
+				item := item{
+					Rule:      lr1p.grammar.Rules[ruleNum],
+					RuleNum:   ruleNum,
+					Position:  0,
+					Lookahead: la,
+				}
+				if k := item.key(); !seen[k] {
+					seen[k] = true
+					it = append(it, item)
+				}
+			}
+		}
+	}
+
+	return it
+}
+
+// kernelKey returns a key of the kernel that does not depend on the order
+// of its items.
+func kernelKey(kernel []item) string {
+	keys := make([]itemKey, len(kernel))
+	for i := range kernel {
+		keys[i] = kernel[i].key()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.rule != b.rule {
+			return a.rule < b.rule
+		}
+		if a.position != b.position {
+			return a.position < b.position
+		}
+		return a.lookahead < b.lookahead
+	})
+
+	var b []byte
+	for _, k := range keys {
+		b = strconv.AppendInt(b, int64(k.rule), 10)
+		b = append(b, '.')
+		b = strconv.AppendInt(b, int64(k.position), 10)
+		b = append(b, '.')
+		b = strconv.AppendInt(b, int64(k.lookahead), 10)
+		b = append(b, ';')
 	}
 
-	return lr1p.closure(j)
+	return string(b)
 }
 
-func setsEqual(s1 []item, s2 []item) bool {
-	if len(s1) != len(s2) {
-		return false
+// items returns the canonical collection of LR(1) states and the goto
+// transitions between them, recorded as the states are found. A state is
+// identified by its kernel, the items its closure starts with.
+func (lr1p *LR1Parser) items() ([][]item, []map[int]int) {
+	lr1p.computeTails()
+
+	start := []item{
+		{
+			Rule:      lr1p.augmentedRule(),
+			RuleNum:   augmented,
+			Position:  0,
+			Lookahead: lr1p.endMarker(),
+		},
 	}
 
-	for i := 0; i < len(s1); i++ {
-		flag := false
+	closures := [][]item{lr1p.closure(start)}
+	index := map[string]int{kernelKey(start): 0}
+	var trans []map[int]int
+
+	allSymbols := lr1p.symbols()
+
+	for i := 0; i < len(closures); i++ {
+		kernels := lr1p.successors(closures[i])
+
+		trans = append(trans, make(map[int]int))
+
+		for _, symbol := range allSymbols {
+			kernel, ok := kernels[symbol]
+			if !ok {
+				continue
+			}
 
-		for j := 0; j < len(s2); j++ {
-			if itemEqual(&s1[i], &s2[j]) {
-				flag = true
-				break
+			key := kernelKey(kernel)
+			k, ok := index[key]
+			if !ok {
+				k = len(closures)
+				index[key] = k
+				closures = append(closures, lr1p.closure(kernel))
 			}
-		}
 
-		if !flag {
-			return false
+			trans[i][symbol] = k
 		}
 	}
 
-	return true
+	return closures, trans
 }
 
-func itemEqual(i1 *item, i2 *item) bool {
-	return i1.RuleNum == i2.RuleNum && i1.Position == i2.Position && i1.Lookahead == i2.Lookahead
+// successors returns the kernels of the states reached from the closure by
+// each symbol ID.
+func (lr1p *LR1Parser) successors(closure []item) map[int][]item {
+	kernels := make(map[int][]item)
+	for _, it := range closure {
+		right := lr1p.right(&it)
+		if it.Position >= len(right) {
+			continue
+		}
+
+		symbol := right[it.Position]
+		it.Position++
+		kernels[symbol] = append(kernels[symbol], it)
+	}
+
+	return kernels
 }
 
-func checkItemIn(it *item, items []item) bool {
-	for i := range items {
-		if itemEqual(it, &items[i]) {
-			return true
+// symbols returns the IDs of the terminals and the nonterminals, which
+// is the order the transitions of a state are numbered in.
+func (lr1p *LR1Parser) symbols() []int {
+	var allSymbols []int
+	for id := 0; id < lr1p.grammar.NumSymbols(); id++ {
+		if id != lr1p.endMarker() {
+			allSymbols = append(allSymbols, id)
 		}
 	}
-	return false
+
+	return allSymbols
 }
 
-func (lr1p *LR1Parser) items() [][]item {
-	closures := [][]item{
-		lr1p.closure(
-			[]item{
-				{
-					Rule:      lr1p.grammar.Rules[0],
-					RuleNum:   0,
-					Position:  0,
-					Lookahead: "$",
-				},
-			},
-		),
-	}
+// endMarker returns the ID of the end marker, which follows the terminals.
+func (lr1p *LR1Parser) endMarker() int {
+	return len(lr1p.grammar.TTokens)
+}
 
-	var allSymbols []string
-	for i := range lr1p.grammar.TTokens {
-		allSymbols = append(allSymbols, lr1p.grammar.TTokens[i].TSymbol)
-	}
-	for i := range lr1p.grammar.NTokens {
-		allSymbols = append(allSymbols, lr1p.grammar.NTokens[i].NTSymbol)
+// coreKey returns a key of the core of the state, its items without the
+// lookaheads.
+func coreKey(items []item) string {
+	var cores [][2]int
+	seen := make(map[[2]int]bool)
+	for _, it := range items {
+		c := [2]int{it.RuleNum, it.Position}
+		if !seen[c] {
+			seen[c] = true
+			cores = append(cores, c)
+		}
 	}
+	sort.Slice(cores, func(i, j int) bool {
+		if cores[i][0] != cores[j][0] {
+			return cores[i][0] < cores[j][0]
+		}
+		return cores[i][1] < cores[j][1]
+	})
 
-	currentLen := len(closures)
-	oldLen := 0
+	return fmt.Sprint(cores)
+}
 
-	for {
-		for i := oldLen; i < currentLen; i++ {
-		l1:
-			for j := range allSymbols {
-				gt := lr1p.goTo(closures[i], allSymbols[j])
+// mergeCores merges the states with equal cores, which gives the LALR(1)
+// states, and returns them with their transitions.
+func mergeCores(closures [][]item, trans []map[int]int) ([][]item, []map[int]int) {
+	class := make([]int, len(closures))
+	index := make(map[string]int)
+	var merged [][]item
+	var seen []map[itemKey]bool
 
-				if len(gt) == 0 {
-					continue
-				}
+	for i := range closures {
+		key := coreKey(closures[i])
 
-				for k := range closures {
-					if setsEqual(gt, closures[k]) {
-						continue l1
-					}
-				}
+		k, ok := index[key]
+		if !ok {
+			k = len(merged)
+			index[key] = k
+			merged = append(merged, nil)
+			seen = append(seen, make(map[itemKey]bool))
+		}
+		class[i] = k
 
-				closures = append(closures, gt)
+		for j := range closures[i] {
+			if ik := closures[i][j].key(); !seen[k][ik] {
+				seen[k][ik] = true
+				merged[k] = append(merged[k], closures[i][j])
 			}
 		}
+	}
+
+	mergedTrans := make([]map[int]int, len(merged))
+	for i := range trans {
+		if mergedTrans[class[i]] == nil {
+			mergedTrans[class[i]] = make(map[int]int)
+		}
+
+		for symbol, k := range trans[i] {
+			mergedTrans[class[i]][symbol] = class[k]
+		}
+	}
+
+	return merged, mergedTrans
+}
+
+// setAction sets the action of the state on the terminal ID.
+func (lr1p *LR1Parser) setAction(st int, t int, act state) {
+	old := lr1p.actionTable[st][t]
+	if old.action == err || old == act {
+		lr1p.actionTable[st][t] = act
+		return
+	}
+
+	symbol := lr1p.grammar.Symbol(t)
 
-		if currentLen == len(closures) {
+	c := -1
+	for i := range lr1p.conflicts {
+		if lr1p.conflicts[i].State == st && lr1p.conflicts[i].Symbol == symbol {
+			c = i
 			break
-		} else {
-			oldLen = currentLen
-			currentLen = len(closures)
 		}
 	}
+	if c < 0 {
+		lr1p.conflicts = append(
+			lr1p.conflicts,
+			Conflict{
+				State:   st,
+				Symbol:  symbol,
+				Actions: []string{actionString(old)},
+			},
+		)
+		c = len(lr1p.conflicts) - 1
+	}
+	lr1p.conflicts[c].Actions = append(lr1p.conflicts[c].Actions, actionString(act))
 
-	return closures
+	// resolve like yacc does: prefer shift to reduce and the earlier rule
+	// of two reduces
+	if act.action == shift || (act.action == reduce && old.action == reduce && act.st < old.st) {
+		lr1p.actionTable[st][t] = act
+	}
 }
 
-func (lr1p *LR1Parser) buildTable() {
-	closures := lr1p.items()
-	tTokens := lr1p.grammar.TTokens
-	ntTokens := lr1p.grammar.NTokens
+// BuildTable builds the ACTION and GOTO tables with the chosen algorithm.
+// Conflicts are resolved in favour of shift and of the earlier rule, and
+// are reported by Conflicts.
+func (lr1p *LR1Parser) BuildTable() {
+	var closures [][]item
+	var trans []map[int]int
+	lr1p.merged = nil
+
+	switch lr1p.algorithm {
+	case LR1:
+		closures, trans = lr1p.items()
+	case Pager:
+		closures, trans = lr1p.minimalItems()
+	default:
+		closures, trans = mergeCores(lr1p.items())
+	}
+
+	lr1p.states = closures
+	lr1p.trans = trans
+	lr1p.compactTable = nil
 
-	lr1p.actionTable = make([]map[string]state, len(closures))
-	lr1p.gotoTable = make([]map[string]int, len(closures))
+	// the rows of the ACTION table are indexed by the terminal IDs and the
+	// rows of the GOTO table by the nonterminals in NTokens
+	lr1p.actionTable = make([][]state, len(closures))
+	lr1p.gotoTable = make([][]int, len(closures))
+	lr1p.conflicts = nil
 
 	for i := range closures {
 		items := closures[i]
 
-		lr1p.actionTable[i] = make(map[string]state)
-		lr1p.gotoTable[i] = make(map[string]int)
+		lr1p.actionTable[i] = make([]state, lr1p.endMarker()+1)
+		lr1p.gotoTable[i] = make([]int, len(lr1p.grammar.NTokens))
 
-		for j := range tTokens {
-			lr1p.actionTable[i][tTokens[j].TSymbol] = state{
+		for t := range lr1p.actionTable[i] {
+			lr1p.actionTable[i][t] = state{
 				action: err,
 			}
 		}
 
 		for j := range items {
 			position := items[j].Position
+			right := lr1p.right(&items[j])
 
-			if position == len(items[j].Rule.RSymbol) && items[j].Rule.LSymbol == lr1p.grammar.Root && items[j].Lookahead == "$" {
-				lr1p.actionTable[i][items[j].Lookahead] = state{
-					action: accept,
+			if position == len(right) {
+				if items[j].RuleNum == augmented {
+					lr1p.setAction(i, lr1p.endMarker(), state{action: accept})
+					continue
+				}
+
+				lookahead := []int{items[j].Lookahead}
+				if lr1p.algorithm == SLR1 {
+					lookahead = nil
+					for _, f := range lr1p.grammar.Follow(items[j].Rule.LSymbol) {
+						lookahead = append(lookahead, lr1p.grammar.ID(f))
+					}
 				}
-				continue
-			}
 
-			if position == len(items[j].Rule.RSymbol) && items[j].Rule.LSymbol != lr1p.grammar.Root {
-				lr1p.actionTable[i][items[j].Lookahead] = state{
-					action: reduce,
-					st:     items[j].RuleNum,
+				for _, la := range lookahead {
+					lr1p.setAction(i, la, state{action: reduce, st: items[j].RuleNum})
 				}
 				continue
 			}
 
-			symbol := items[j].Rule.RSymbol[position : position+1]
+			symbol := right[position]
 
-			if lr1p.grammar.TokenType(symbol) == grammar.Term {
-				for k := range closures {
-					if setsEqual(lr1p.goTo(items, symbol), closures[k]) {
-						lr1p.actionTable[i][symbol] = state{
-							action: shift,
-							st:     k,
-						}
-					}
-				}
+			if lr1p.grammar.Kind(symbol) == grammar.Term {
+				lr1p.setAction(i, symbol, state{action: shift, st: trans[i][symbol]})
 			}
 		}
 
-		for j := range ntTokens {
-			nts := ntTokens[j].NTSymbol
-			for k := range closures {
-				if setsEqual(lr1p.goTo(items, nts), closures[k]) {
-					lr1p.gotoTable[i][nts] = k
-					break
-				} else {
-					lr1p.gotoTable[i][nts] = -1
-				}
+		for nt := range lr1p.grammar.NTokens {
+			k, ok := trans[i][lr1p.endMarker()+1+nt]
+			if !ok {
+				k = -1
 			}
+			lr1p.gotoTable[i][nt] = k
 		}
 	}
+}
+
+// Conflicts returns the conflicts found by BuildTable.
+func (lr1p *LR1Parser) Conflicts() []Conflict {
+	return lr1p.conflicts
+}
+
+// TableReport returns the ACTION and GOTO tables.
+func (lr1p *LR1Parser) TableReport() report.Table {
+	if lr1p.actionTable == nil {
+		lr1p.BuildTable()
+	}
+
+	terms := lr1p.terminals()
+	ntTokens := lr1p.grammar.NTokens
+	states := len(lr1p.actionTable)
 
-	data := make([][]string, 1+len(closures))
-	data[0] = make([]string, 1+len(ntTokens)+len(tTokens))
-	data[0][0] = "State"
-	for i := 1; i < 1+len(closures); i++ {
-		data[i] = make([]string, 1+len(ntTokens)+len(tTokens))
-		data[i][0] = fmt.Sprintf("%d", i)
+	table := report.Table{
+		Title:    fmt.Sprintf("%s table", lr1p.algorithm),
+		Header:   make([]string, 1+len(terms)+len(ntTokens)),
+		Rows:     make([][]string, states),
+		Bordered: true,
+	}
+	table.Header[0] = "State"
+	for i := 0; i < states; i++ {
+		table.Rows[i] = make([]string, 1+len(terms)+len(ntTokens))
+		table.Rows[i][0] = fmt.Sprintf("%d", i)
 	}
-	for i := range lr1p.grammar.TTokens {
-		for j := 0; j < len(closures); j++ {
-			action := lr1p.actionTable[j][lr1p.grammar.TTokens[i].TSymbol].action
-			state := lr1p.actionTable[j][lr1p.grammar.TTokens[i].TSymbol].st
+	for i := range terms {
+		for j := 0; j < states; j++ {
+			action := lr1p.actionTable[j][i].action
+			state := lr1p.actionTable[j][i].st
 			var str string
 			switch action {
 			case accept:
-				str = fmt.Sprintf("\033[1;32m\u2714\033[0m")
+				str = "\u2714"
 			case shift:
-				str = fmt.Sprintf("\033[1;33ms%d\033[0m", state)
+				str = fmt.Sprintf("s%d", state)
 			case reduce:
-				str = fmt.Sprintf("\033[1;34mr%d\033[0m", state)
+				str = fmt.Sprintf("r%d", state)
 			default:
 				str = ""
 			}
-			data[1+j][1+i] = str
+			table.Rows[j][1+i] = str
 		}
-		data[0][1+i] = lr1p.grammar.TTokens[i].TSymbol
+		table.Header[1+i] = terms[i]
 	}
-	for i := range lr1p.grammar.NTokens {
-		for j := 0; j < len(closures); j++ {
-			state := lr1p.gotoTable[j][lr1p.grammar.NTokens[i].NTSymbol]
+	for i := range ntTokens {
+		for j := 0; j < states; j++ {
+			state := lr1p.gotoTable[j][i]
 			if state >= 0 {
-				data[1+j][1+len(lr1p.grammar.TTokens)+i] = fmt.Sprintf("%d", state)
+				table.Rows[j][1+len(terms)+i] = fmt.Sprintf("%d", state)
 			}
 		}
-		data[0][1+len(lr1p.grammar.TTokens)+i] = lr1p.grammar.NTokens[i].NTSymbol
+		table.Header[1+len(terms)+i] = ntTokens[i].NTSymbol
+	}
+
+	actions := lr1p.actionTable
+	table.Style = func(row, col int) report.Style {
+		if col == 0 || col > len(terms) {
+			return report.NoStyle
+		}
+
+		return actionStyles[actions[row][col-1].action]
+	}
+
+	return table
+}
+
+var actionStyles = map[int]report.Style{
+	accept: report.BoldGreen,
+	shift:  report.BoldYellow,
+	reduce: report.BoldBlue,
+}
+
+// ConflictsReport returns the conflicts found by BuildTable.
+func (lr1p *LR1Parser) ConflictsReport() report.Table {
+	table := report.Table{
+		Title:  "Conflicts",
+		Header: []string{"State", "Symbol", "Actions"},
+	}
+
+	for _, c := range lr1p.conflicts {
+		table.Rows = append(table.Rows, []string{fmt.Sprintf("%d", c.State), c.Symbol, strings.Join(c.Actions, " ")})
+	}
+
+	return table
+}
+
+// PrintTable prints the ACTION and GOTO tables.
+func (lr1p *LR1Parser) PrintTable() {
+	report.Write(os.Stdout, report.Color, lr1p.TableReport())
+}
+
+// terminals returns the terminals and the end marker.
+func (lr1p *LR1Parser) terminals() []string {
+	var terms []string
+	for _, tt := range lr1p.grammar.TTokens {
+		terms = append(terms, tt.TSymbol)
+	}
+
+	return append(terms, grammar.EndMarker)
+}
+
+// StepsReport returns the steps taken by the last call to Parse.
+func (lr1p *LR1Parser) StepsReport() report.Table {
+	table := report.Table{
+		Title:  "Steps",
+		Header: []string{"States", "Symbols", "Input", "Action"},
+	}
+
+	for _, st := range lr1p.steps {
+		states := make([]string, len(st.States))
+		for i := range st.States {
+			states[i] = fmt.Sprintf("%d", st.States[i])
+		}
+
+		table.Rows = append(
+			table.Rows,
+			[]string{
+				strings.Join(states, " "),
+				strings.Join(st.Symbols, ""),
+				st.Input,
+				st.Action,
+			},
+		)
+	}
+
+	return table
+}
+
+// PrintSteps prints the steps taken by the last call to Parse.
+func (lr1p *LR1Parser) PrintSteps() {
+	report.Write(os.Stdout, report.Color, lr1p.StepsReport())
+}
+
+// Result returns the value of the start symbol computed by the semantic
+// actions during the last call to Parse. Terminals have their input
+// character as the value.
+func (lr1p *LR1Parser) Result() grammar.Value {
+	return lr1p.result
+}
+
+// Production returns the rules of the reductions made by the last call to
+// Parse, which is a rightmost derivation in reverse.
+func (lr1p *LR1Parser) Production() []int {
+	return lr1p.production
+}
+
+// lookahead returns the next input symbol, the end marker at the end.
+func (lr1p *LR1Parser) lookahead() string {
+	if lr1p.inputIter >= len(lr1p.input) {
+		return grammar.EndMarker
 	}
 
-	lr1p.printer.AppendBulk(data)
-	lr1p.printer.Render()
+	return lr1p.input[lr1p.inputIter : lr1p.inputIter+1]
 }
 
 func (lr1p *LR1Parser) Parse() error {
+	lr1p.stateStack = []int{0}
+	lr1p.symbolStack = nil
+	lr1p.valueStack = nil
+	lr1p.inputIter = 0
 	lr1p.production = make([]int, 0)
+	lr1p.steps = make([]Step, 0)
+	lr1p.result = nil
 
-	lr1p.buildTable()
+	if lr1p.actionTable == nil {
+		lr1p.BuildTable()
+	}
+	if lr1p.compact && lr1p.compactTable == nil {
+		lr1p.compactTable = lr1p.buildCompact()
+	}
 
 l1:
 	for {
 		s := lr1p.stateStack[0]
-		a := lr1p.input[lr1p.inputIter : lr1p.inputIter+1]
-		act, ok := lr1p.actionTable[s][a]
-		if !ok {
-			return fmt.Errorf("error")
-		}
+		a := lr1p.lookahead()
+		act := lr1p.action(s, lr1p.grammar.ID(a))
+
+		lr1p.addStep(act)
 
 		switch act.action {
 		case shift:
 			lr1p.stackPush(act.st)
+			lr1p.symbolPush(a)
+			lr1p.valuePush(a)
 			lr1p.inputIter++
 		case reduce:
 			rule := lr1p.grammar.Rules[act.st]
-			lr1p.stackPop(len(rule.RSymbol))
+			n := len(lr1p.grammar.RightIDs(act.st))
+			lr1p.stackPop(n)
+			lr1p.symbolPop(n)
 			s = lr1p.stateStack[0]
-			lr1p.stackPush(lr1p.gotoTable[s][rule.LSymbol])
+			lr1p.stackPush(lr1p.goTo(s, lr1p.grammar.LeftID(act.st)))
+			lr1p.symbolPush(rule.LSymbol)
+			lr1p.valuePush(lr1p.reduceValue(rule))
 			lr1p.production = append(lr1p.production, act.st)
 		case accept:
+			lr1p.result = lr1p.valueStack[0]
 			break l1
 		default:
-			return fmt.Errorf("error")
+			return fmt.Errorf("unexpected symbol %q at position %d", a, lr1p.inputIter)
 		}
 	}
 
-	fmt.Println(lr1p.production)
-
 	return nil
 }
@@ -0,0 +1,300 @@
+package lr1parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/internal/goldentest"
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/parsetree"
+)
+
+func TestParseActions(t *testing.T) {
+	digit := func(args []grammar.Value) grammar.Value {
+		return int(args[0].(string)[0] - '0')
+	}
+	binary := func(op func(a, b int) int) grammar.Action {
+		return func(args []grammar.Value) grammar.Value {
+			return op(args[0].(int), args[2].(int))
+		}
+	}
+
+	gr, err := grammar.New(grammar.GrammarSettings{
+		Root:      "S",
+		TSymbols:  []string{"+", "*", "(", ")", "1", "2", "3"},
+		NTSymbols: []string{"S", "E", "T", "F"},
+		Rules: []grammar.Rule{
+			{LSymbol: "S", RSymbol: "E"},
+			{LSymbol: "E", RSymbol: "E+T", Action: binary(func(a, b int) int { return a + b })},
+			{LSymbol: "E", RSymbol: "T"},
+			{LSymbol: "T", RSymbol: "T*F", Action: binary(func(a, b int) int { return a * b })},
+			{LSymbol: "T", RSymbol: "F"},
+			{LSymbol: "F", RSymbol: "(E)", Action: func(args []grammar.Value) grammar.Value { return args[1] }},
+			{LSymbol: "F", RSymbol: "1", Action: digit},
+			{LSymbol: "F", RSymbol: "2", Action: digit},
+			{LSymbol: "F", RSymbol: "3", Action: digit},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		value int
+	}{
+		{"1", 1},
+		{"1+2*3", 7},
+		{"(1+2)*3", 9},
+		{"2*(3+3)*(1)", 12},
+	}
+
+	for _, algo := range []Algorithm{LR1, LALR1, SLR1, Pager} {
+		for _, tt := range tests {
+			lr1p := NewLR1Parser(*gr, tt.input)
+			lr1p.SetAlgorithm(algo)
+
+			if err := lr1p.Parse(); err != nil {
+				t.Errorf("%v: %q: %v", algo, tt.input, err)
+				continue
+			}
+
+			if v, ok := lr1p.Result().(int); !ok || v != tt.value {
+				t.Errorf("%v: %q = %v, want %d", algo, tt.input, lr1p.Result(), tt.value)
+			}
+		}
+	}
+}
+
+func TestParseAnyRoot(t *testing.T) {
+	// the root has two rules, neither of them first, and is used on the
+	// right side
+	gr, err := grammar.New(grammar.GrammarSettings{
+		Root:      "B",
+		TSymbols:  []string{"+", "*", "a", "(", ")"},
+		NTSymbols: []string{"B", "T", "M"},
+		Rules: []grammar.Rule{
+			{LSymbol: "T", RSymbol: "M*T"},
+			{LSymbol: "T", RSymbol: "M"},
+			{LSymbol: "B", RSymbol: "T+B"},
+			{LSymbol: "M", RSymbol: "a"},
+			{LSymbol: "B", RSymbol: "T"},
+			{LSymbol: "M", RSymbol: "(B)"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range []Algorithm{LR1, LALR1, SLR1, Pager} {
+		for _, in := range []string{"a", "a+a", "a*a", "(a+a)*a+a"} {
+			lr1p := NewLR1Parser(*gr, in)
+			lr1p.SetAlgorithm(a)
+			if err := lr1p.Parse(); err != nil {
+				t.Errorf("%v: %q: %v", a, in, err)
+				continue
+			}
+
+			tree, err := parsetree.FromRightmost(gr, lr1p.Production())
+			if err != nil {
+				t.Errorf("%v: %q: %v", a, in, err)
+				continue
+			}
+			if tree.Yield() != in {
+				t.Errorf("%v: %q: the parse tree yields %q", a, in, tree.Yield())
+			}
+		}
+	}
+}
+
+// TestParseSimplified parses with a grammar without unit rules, whose root
+// has several rules, and translates the reductions back to the original
+// grammar.
+func TestParseSimplified(t *testing.T) {
+	gr, err := grammar.Read(strings.NewReader(`
+S -> E
+E -> E+T | T
+T -> T*F | F
+F -> (E) | a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	simple, origin, err := gr.RemoveUnitRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(simple.NTokens[simple.FindNToken("S")].Alt) < 2 {
+		t.Fatalf("the root of the simplified grammar has a single rule")
+	}
+
+	for _, in := range []string{"a", "a+a*a", "(a+a)*a"} {
+		lr1p := NewLR1Parser(*gr, in)
+		if err := lr1p.Parse(); err != nil {
+			t.Fatalf("%q: %v", in, err)
+		}
+		want := fmt.Sprint(lr1p.Production())
+
+		lr1p = NewLR1Parser(*simple, in)
+		if err := lr1p.Parse(); err != nil {
+			t.Errorf("%q: simplified grammar: %v", in, err)
+			continue
+		}
+
+		if got := fmt.Sprint(origin.Rightmost(lr1p.Production())); got != want {
+			t.Errorf("%q: got reductions %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestPager(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar string
+		like    Algorithm // the algorithm with as many states
+	}{
+		{
+			// merging the states after c is a reduce/reduce conflict, so
+			// they are kept apart as in LR(1)
+			name: "not LALR(1)",
+			grammar: `
+S -> aAd | bBd | aBe | bAe
+A -> c
+B -> c
+`,
+			like: LR1,
+		},
+		{
+			name: "expressions",
+			grammar: `
+E -> E+T | T
+T -> T*F | F
+F -> (E) | a
+`,
+			like: LALR1,
+		},
+	}
+
+	for _, tt := range tests {
+		gr, err := grammar.Read(strings.NewReader(tt.grammar))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pager := NewLR1Parser(*gr, "")
+		pager.SetAlgorithm(Pager)
+		pager.BuildTable()
+
+		like := NewLR1Parser(*gr, "")
+		like.SetAlgorithm(tt.like)
+		like.BuildTable()
+
+		if len(pager.Conflicts()) > 0 {
+			t.Errorf("%s: conflicts %v", tt.name, pager.Conflicts())
+		}
+		if got, want := len(pager.states), len(like.states); got != want {
+			t.Errorf("%s: %d states, want %d as %v", tt.name, got, want, tt.like)
+		}
+
+		// only the states merged like LALR(1) record merged states
+		if merged := pager.MergedStates(); (len(merged) > 0) != (tt.like == LALR1) {
+			t.Errorf("%s: merged states %v", tt.name, merged)
+		}
+		if merged := like.MergedStates(); len(merged) > 0 {
+			t.Errorf("%s: %v has merged states %v", tt.name, tt.like, merged)
+		}
+	}
+}
+
+// TestMergedStates checks that the merged states of Pager add up to the
+// states of the canonical LR(1) automaton.
+func TestMergedStates(t *testing.T) {
+	cases, err := goldentest.Cases(goldentest.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the states of cc get new lookaheads and are processed again, the
+	// states after c of lr1-not-lalr are kept apart
+	want := map[string]string{
+		"cc":           "map[1:1 2:1 6:1]",
+		"lr1-not-lalr": "map[]",
+	}
+
+	for _, c := range cases {
+		pager := NewLR1Parser(*c.Grammar, "")
+		pager.SetAlgorithm(Pager)
+		pager.BuildTable()
+
+		lr1 := NewLR1Parser(*c.Grammar, "")
+		lr1.BuildTable()
+
+		merged := pager.MergedStates()
+		total := 0
+		for _, n := range merged {
+			total += n
+		}
+		if saved := len(lr1.states) - len(pager.states); total != saved {
+			t.Errorf("%s: %d states merged in %v, want %d", c.Name, total, merged, saved)
+		}
+
+		if w, ok := want[c.Name]; ok {
+			delete(want, c.Name)
+			if got := fmt.Sprint(merged); got != w {
+				t.Errorf("%s: merged states %s, want %s", c.Name, got, w)
+			}
+		}
+	}
+
+	for name := range want {
+		t.Errorf("no case %s", name)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	cases, cerr := goldentest.Cases(goldentest.Dir)
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+
+	for _, c := range cases {
+		for _, a := range []Algorithm{LR1, LALR1, SLR1, Pager} {
+			dense := NewLR1Parser(*c.Grammar, "")
+			dense.SetAlgorithm(a)
+			dense.BuildTable()
+
+			ct := dense.buildCompact()
+			for st := range dense.actionTable {
+				for id, act := range dense.actionTable[st] {
+					if got := ct.lookup(st, id).state(); act.action != err && got != act {
+						t.Errorf("%s, %v: state %d, symbol %s: got %v, want %v", c.Name, a, st, c.Grammar.Symbol(id), got, act)
+					}
+				}
+				for nt, k := range dense.gotoTable[st] {
+					id := dense.endMarker() + 1 + nt
+					if got := ct.lookup(st, id).state(); k >= 0 && got != (state{action: shift, st: k}) {
+						t.Errorf("%s, %v: state %d, goto %s: got %v, want %d", c.Name, a, st, c.Grammar.Symbol(id), got, k)
+					}
+				}
+			}
+
+			compact := NewLR1Parser(*c.Grammar, "")
+			compact.SetAlgorithm(a)
+			compact.SetCompact(true)
+
+			for _, in := range c.Inputs {
+				dense.SetInput(in)
+				compact.SetInput(in)
+				want, got := dense.Parse(), compact.Parse()
+
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("%s, %v: %q: got %v, want %v", c.Name, a, in, got, want)
+				} else if want == nil && fmt.Sprint(compact.Production()) != fmt.Sprint(dense.Production()) {
+					t.Errorf("%s, %v: %q: got reductions %v, want %v", c.Name, a, in, compact.Production(), dense.Production())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package lr1parser
+
+import (
+	"fmt"
+
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// weaklyCompatible reports whether the kernels, which have the same core,
+// can be merged without adding reduce/reduce conflicts that the canonical
+// LR(1) automaton does not have. This is the weak compatibility of Pager:
+// for all pairs of items i and j the lookaheads do not mix, L1[i] ∩ L2[j]
+// and L1[j] ∩ L2[i] are empty, or the lookaheads of i and j meet in one of
+// the kernels already.
+func weaklyCompatible(k1, k2 []item) bool {
+	l1, l2 := kernelLookaheads(k1), kernelLookaheads(k2)
+
+	cores := make([][2]int, 0, len(l1))
+	for c := range l1 {
+		cores = append(cores, c)
+	}
+
+	for a := range cores {
+		for b := a + 1; b < len(cores); b++ {
+			i, j := cores[a], cores[b]
+			if !meets(l1[i], l2[j]) && !meets(l1[j], l2[i]) {
+				continue
+			}
+			if meets(l1[i], l1[j]) || meets(l2[i], l2[j]) {
+				continue
+			}
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// kernelLookaheads returns the lookaheads of each core of the kernel.
+func kernelLookaheads(kernel []item) map[[2]int]map[int]bool {
+	la := make(map[[2]int]map[int]bool)
+	for _, it := range kernel {
+		c := [2]int{it.RuleNum, it.Position}
+		if la[c] == nil {
+			la[c] = make(map[int]bool)
+		}
+		la[c][it.Lookahead] = true
+	}
+
+	return la
+}
+
+func meets(s1, s2 map[int]bool) bool {
+	for t := range s1 {
+		if s2[t] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// minimalItems returns the states of Pager's minimal LR(1) automaton and
+// the goto transitions between them. It builds the canonical collection
+// but merges a new state into a state with the same core if they are
+// weakly compatible. A state that gets new lookaheads is processed again,
+// so they reach its successors, and the states that are no longer reached
+// are dropped at the end.
+func (lr1p *LR1Parser) minimalItems() ([][]item, []map[int]int) {
+	lr1p.computeTails()
+
+	kernels := [][]item{{
+		{
+			Rule:      lr1p.augmentedRule(),
+			RuleNum:   augmented,
+			Position:  0,
+			Lookahead: lr1p.endMarker(),
+		},
+	}}
+	byCore := map[string][]int{coreKey(kernels[0]): {0}}
+	trans := []map[int]int{nil}
+
+	queue := []int{0}
+	queued := []bool{true}
+
+	allSymbols := lr1p.symbols()
+
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		queued[i] = false
+
+		successors := lr1p.successors(lr1p.closure(append([]item(nil), kernels[i]...)))
+
+		trans[i] = make(map[int]int)
+
+		for _, symbol := range allSymbols {
+			kernel, ok := successors[symbol]
+			if !ok {
+				continue
+			}
+
+			key := coreKey(kernel)
+			target := -1
+			for _, c := range byCore[key] {
+				if weaklyCompatible(kernels[c], kernel) {
+					target = c
+					break
+				}
+			}
+
+			if target < 0 {
+				target = len(kernels)
+				kernels = append(kernels, kernel)
+				byCore[key] = append(byCore[key], target)
+				trans = append(trans, nil)
+				queue = append(queue, target)
+				queued = append(queued, true)
+			} else if mergeKernel(&kernels[target], kernel) && !queued[target] {
+				queue = append(queue, target)
+				queued[target] = true
+			}
+
+			trans[i][symbol] = target
+		}
+	}
+
+	// keep the states still reached from the start, in their order
+	reached := make([]bool, len(kernels))
+	reached[0] = true
+	for stack := []int{0}; len(stack) > 0; {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, k := range trans[i] {
+			if !reached[k] {
+				reached[k] = true
+				stack = append(stack, k)
+			}
+		}
+	}
+
+	number := make([]int, len(kernels))
+	var closures [][]item
+	for i := range kernels {
+		if reached[i] {
+			number[i] = len(closures)
+			closures = append(closures, lr1p.closure(kernels[i]))
+		}
+	}
+
+	var minimalTrans []map[int]int
+	for i := range kernels {
+		if !reached[i] {
+			continue
+		}
+
+		t := make(map[int]int)
+		for symbol, k := range trans[i] {
+			t[symbol] = number[k]
+		}
+		minimalTrans = append(minimalTrans, t)
+	}
+
+	return closures, minimalTrans
+}
+
+// mergedCounts returns the number of the canonical LR(1) states merged
+// into each state of the Pager automaton besides the first. It builds the
+// canonical collection and follows its transitions from the start state
+// along with the transitions of the automaton, so each canonical state is
+// counted in the state it is parsed in.
+func (lr1p *LR1Parser) mergedCounts() []int {
+	_, canonical := lr1p.items()
+
+	state := make([]int, len(canonical))
+	for i := range state {
+		state[i] = -1
+	}
+	state[0] = 0
+
+	counts := make([]int, len(lr1p.states))
+	for queue := []int{0}; len(queue) > 0; queue = queue[1:] {
+		c := queue[0]
+		counts[state[c]]++
+
+		for symbol, k := range canonical[c] {
+			if state[k] < 0 {
+				state[k] = lr1p.trans[state[c]][symbol]
+				queue = append(queue, k)
+			}
+		}
+	}
+
+	for st := range counts {
+		if counts[st] > 0 {
+			counts[st]--
+		}
+	}
+
+	return counts
+}
+
+// MergedStates returns the states of the Pager automaton that canonical
+// LR(1) states were merged into, with the number of the canonical states
+// merged besides the first, so the counts add up to the states Pager
+// saves. It builds the canonical collection on the first call and is
+// empty for the other algorithms.
+func (lr1p *LR1Parser) MergedStates() map[int]int {
+	if lr1p.actionTable == nil {
+		lr1p.BuildTable()
+	}
+	if lr1p.algorithm == Pager && lr1p.merged == nil {
+		lr1p.merged = lr1p.mergedCounts()
+	}
+
+	merged := make(map[int]int)
+	for st, n := range lr1p.merged {
+		if n > 0 {
+			merged[st] = n
+		}
+	}
+
+	return merged
+}
+
+// MergedReport returns the states of MergedStates in their order.
+func (lr1p *LR1Parser) MergedReport() report.Table {
+	merged := lr1p.MergedStates()
+
+	table := report.Table{
+		Title:  "Merged states",
+		Header: []string{"State", "Merged LR(1) states"},
+	}
+
+	total := 0
+	for st := range lr1p.states {
+		if n, ok := merged[st]; ok {
+			table.Rows = append(table.Rows, []string{fmt.Sprintf("%d", st), fmt.Sprintf("%d", n)})
+			total += n
+		}
+	}
+	table.Rows = append(table.Rows, []string{"Total", fmt.Sprintf("%d", total)})
+
+	return table
+}
+
+// mergeKernel adds the items of the kernel missing from dst and reports
+// whether there were any.
+func mergeKernel(dst *[]item, kernel []item) bool {
+	seen := make(map[itemKey]bool, len(*dst))
+	for i := range *dst {
+		seen[(*dst)[i].key()] = true
+	}
+
+	added := false
+	for i := range kernel {
+		if !seen[kernel[i].key()] {
+			*dst = append(*dst, kernel[i])
+			added = true
+		}
+	}
+
+	return added
+}
@@ -0,0 +1,142 @@
+package lr1parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+var exprSettings = grammar.GrammarSettings{
+	Root:      "S",
+	TSymbols:  []string{"+", "*", "(", ")", "a"},
+	NTSymbols: []string{"S", "E", "T", "F"},
+	Rules: []grammar.Rule{
+		{LSymbol: "S", RSymbol: "E"},
+		{LSymbol: "E", RSymbol: "E+T"},
+		{LSymbol: "E", RSymbol: "T"},
+		{LSymbol: "T", RSymbol: "T*F"},
+		{LSymbol: "T", RSymbol: "F"},
+		{LSymbol: "F", RSymbol: "(E)"},
+		{LSymbol: "F", RSymbol: "a"},
+	},
+}
+
+// statesString returns the items of the states by their numbers.
+func statesString(states [][]item) string {
+	var b strings.Builder
+	for i, st := range states {
+		fmt.Fprintf(&b, "%d:", i)
+		for _, it := range st {
+			fmt.Fprintf(&b, " %d.%d/%v", it.RuleNum, it.Position, it.Lookahead)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func TestStateNumbering(t *testing.T) {
+	var want string
+
+	for i := 0; i < 20; i++ {
+		gr, err := grammar.New(exprSettings)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lr1p := NewLR1Parser(*gr, "")
+		states, _ := lr1p.items()
+		got := statesString(states)
+
+		if i == 0 {
+			want = got
+			continue
+		}
+		if got != want {
+			t.Fatalf("run %d numbers the states\n%s\nwant\n%s", i, got, want)
+		}
+	}
+}
+
+// cores returns the number of distinct cores of the states.
+func cores(states [][]item) int {
+	seen := make(map[string]bool)
+	for _, st := range states {
+		core := make(map[string]bool)
+		for _, it := range st {
+			core[fmt.Sprintf("%d.%d", it.RuleNum, it.Position)] = true
+		}
+
+		var keys []string
+		for c := range core {
+			keys = append(keys, c)
+		}
+		sort.Strings(keys)
+		seen[strings.Join(keys, " ")] = true
+	}
+
+	return len(seen)
+}
+
+func TestLALRStates(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings grammar.GrammarSettings
+	}{
+		{"expressions", exprSettings},
+		{
+			// the grammar of c-strings of the dragon book
+			"pairs",
+			grammar.GrammarSettings{
+				Root:      "Z",
+				TSymbols:  []string{"c", "d"},
+				NTSymbols: []string{"Z", "S", "C"},
+				Rules: []grammar.Rule{
+					{LSymbol: "Z", RSymbol: "S"},
+					{LSymbol: "S", RSymbol: "CC"},
+					{LSymbol: "C", RSymbol: "cC"},
+					{LSymbol: "C", RSymbol: "d"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		gr, err := grammar.New(tt.settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lr1 := NewLR1Parser(*gr, "")
+		lr1.BuildTable()
+
+		lalr := NewLR1Parser(*gr, "")
+		lalr.SetAlgorithm(LALR1)
+		lalr.BuildTable()
+
+		slr := NewLR1Parser(*gr, "")
+		slr.SetAlgorithm(SLR1)
+		slr.BuildTable()
+
+		canonical := len(lr1.actionTable)
+		if len(lalr.actionTable) >= canonical {
+			t.Errorf("%s: %d LALR(1) states, want fewer than the %d LR(1) states", tt.name, len(lalr.actionTable), canonical)
+		}
+		states, _ := lr1.items()
+		if want := cores(states); len(lalr.actionTable) != want {
+			t.Errorf("%s: %d LALR(1) states, want one per core, %d", tt.name, len(lalr.actionTable), want)
+		}
+		if len(slr.actionTable) != len(lalr.actionTable) {
+			t.Errorf("%s: %d SLR(1) states, want %d as LALR(1)", tt.name, len(slr.actionTable), len(lalr.actionTable))
+		}
+
+		for _, p := range []LR1Parser{lr1, lalr, slr} {
+			if len(p.Conflicts()) > 0 {
+				t.Errorf("%s: %v conflicts %v", tt.name, p.algorithm, p.Conflicts())
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package lrparser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/ll1parser"
+)
+
+// FuzzParse checks that parsing never panics and that the backtracking
+// parser finds the same leftmost derivation as the LL(1) parser, for an
+// LL(1) grammar where the derivation is unique.
+func FuzzParse(f *testing.F) {
+	for _, in := range []string{"", "a", "a+a*a", "(a+a)*a", "a+", "((a)", "$", "a$"} {
+		f.Add(in)
+	}
+
+	gr, err := grammar.New(grammar.GrammarSettings{
+		Root:      "E",
+		TSymbols:  []string{"+", "*", "(", ")", "a"},
+		NTSymbols: []string{"E", "R", "T", "Q", "F"},
+		Rules: []grammar.Rule{
+			{LSymbol: "E", RSymbol: "TR"},
+			{LSymbol: "R", RSymbol: "+TR"},
+			{LSymbol: "R", RSymbol: ""},
+			{LSymbol: "T", RSymbol: "FQ"},
+			{LSymbol: "Q", RSymbol: "*FQ"},
+			{LSymbol: "Q", RSymbol: ""},
+			{LSymbol: "F", RSymbol: "(E)"},
+			{LSymbol: "F", RSymbol: "a"},
+		},
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, in string) {
+		lrp := NewLRParser(*gr, in)
+		lrp.SetMaxSteps(10000)
+		err := lrp.Parse()
+		if _, ok := err.(*LimitError); ok || in == "" {
+			return
+		}
+
+		ll1p := ll1parser.NewLL1Parser(*gr, in)
+		llErr := ll1p.Parse()
+
+		got := fmt.Sprint(err == nil, lrp.Production())
+		want := fmt.Sprint(llErr == nil, ll1p.Production())
+		if err != nil || llErr != nil {
+			got, want = fmt.Sprint(err == nil), fmt.Sprint(llErr == nil)
+		}
+		if got != want {
+			t.Errorf("%q: got %s, LL(1) parser gives %s", in, got, want)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package lrparser
+
+import (
+	"testing"
+
+	"github.com/svkirillov/translator-labs/internal/goldentest"
+)
+
+func TestGolden(t *testing.T) {
+	goldentest.Run(t, goldentest.Dir, func(t *testing.T, c *goldentest.Case) map[string]string {
+		results := goldentest.Results(c.Inputs, func(in string) ([]int, error) {
+			lrp := NewLRParser(*c.Grammar, in)
+			lrp.SetMaxSteps(10000)
+			lrp.SetMaxDepth(200)
+			err := lrp.Parse()
+			return lrp.Production(), err
+		})
+
+		return map[string]string{"lr": goldentest.Text(results)}
+	})
+}
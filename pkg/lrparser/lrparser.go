@@ -1,35 +1,95 @@
 package lrparser
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-
-	"github.com/olekukonko/tablewriter"
+	"strings"
+	"time"
 
 	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/report"
 )
 
 type LRParser struct {
 	grammar    *grammar.Grammar
 	input      string
+	inputIDs   []int
 	l1Stack    []l1StackNode
 	l2Stack    []l2StackNode
 	state      int
 	production []int
 	inputIter  int
+	steps      []Step
+	trace      bool
+
+	maxSteps int
+	maxDepth int
+	timeout  time.Duration
+}
+
+// Step is a single step of the parser: its state, both stacks and the
+// remaining input.
+type Step struct {
+	State string
+	L1    string
+	L2    string
+	Input string
+}
+
+// Limit identifies the parser limit that stopped parsing.
+type Limit int
+
+const (
+	LimitSteps Limit = iota
+	LimitDepth
+	LimitTimeout
+	LimitCanceled
+)
+
+func (l Limit) String() string {
+	switch l {
+	case LimitSteps:
+		return "step limit"
+	case LimitDepth:
+		return "stack depth limit"
+	case LimitTimeout:
+		return "timeout"
+	case LimitCanceled:
+		return "cancellation"
+	default:
+		return "unknown limit"
+	}
+}
 
-	printer *tablewriter.Table
+// LimitError is returned by Parse when a limit is hit. Steps holds the
+// trace up to that point if it is recorded, see SetTrace.
+type LimitError struct {
+	Limit Limit
+	Taken int // number of steps taken
+	Steps []Step
+	Err   error // context error for LimitTimeout and LimitCanceled
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("parsing stopped by %s after %d steps", e.Limit, e.Taken)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
 }
 
 type l1StackNode struct {
-	token     string
+	symbol    int // the ID of the symbol in the grammar
 	tokenType int
 	altCount  int // number of alternative in rules
 	altNum    int // current number of alternative in rules
+	rule      int // number of the rule applied for the current alternative
 }
 
 type l2StackNode struct {
-	token     string
+	symbol    int
 	tokenType int
 }
 
@@ -40,45 +100,51 @@ const (
 	end
 )
 
+// pushL1Stack pushes the node. The tops of the stacks are their last
+// nodes, so a step costs the same however deep the stacks are.
 func (lrp *LRParser) pushL1Stack(l1Token l1StackNode) {
-	newL1Stack := make([]l1StackNode, 1)
-	newL1Stack[0] = l1Token
-	newL1Stack = append(newL1Stack, lrp.l1Stack...)
-	lrp.l1Stack = newL1Stack
+	lrp.l1Stack = append(lrp.l1Stack, l1Token)
 }
 
-func (lrp *LRParser) pushL2Stack(l2Token l2StackNode) {
-	newL2Stack := make([]l2StackNode, len(l2Token.token))
-
-	for i, s := range l2Token.token {
-		newL2Stack[i].token = string(s)
-		newL2Stack[i].tokenType = lrp.grammar.TokenType(string(s))
+// pushL2Stack pushes the symbols with the first one on top.
+func (lrp *LRParser) pushL2Stack(symbols []int) {
+	for i := len(symbols) - 1; i >= 0; i-- {
+		lrp.l2Stack = append(lrp.l2Stack, l2StackNode{
+			symbol:    symbols[i],
+			tokenType: lrp.grammar.Kind(symbols[i]),
+		})
 	}
+}
+
+// popL2Stack pops n nodes off the L2 stack.
+func (lrp *LRParser) popL2Stack(n int) {
+	lrp.l2Stack = lrp.l2Stack[:len(lrp.l2Stack)-n]
+}
+
+func (lrp *LRParser) popL1Stack() {
+	lrp.l1Stack = lrp.l1Stack[:len(lrp.l1Stack)-1]
+}
+
+func (lrp *LRParser) l1Top() *l1StackNode {
+	return &lrp.l1Stack[len(lrp.l1Stack)-1]
+}
 
-	newL2Stack = append(newL2Stack, lrp.l2Stack...)
-	lrp.l2Stack = newL2Stack
+func (lrp *LRParser) l2Top() *l2StackNode {
+	return &lrp.l2Stack[len(lrp.l2Stack)-1]
 }
 
 func NewLRParser(gr grammar.Grammar, in string) LRParser {
 	var l2Stack []l2StackNode
-	for _, nt := range gr.NTokens {
-		if nt.NTSymbol == gr.Root {
-			l2Stack = append(
-				l2Stack,
-				l2StackNode{
-					token:     nt.NTSymbol,
-					tokenType: grammar.NTerm,
-				},
-			)
-		}
+	if id := gr.ID(gr.Root); id >= 0 {
+		l2Stack = append(
+			l2Stack,
+			l2StackNode{
+				symbol:    id,
+				tokenType: grammar.NTerm,
+			},
+		)
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.SetBorder(false)
-	table.SetHeader([]string{"State", "L1", "L2", "Input"})
-
 	return LRParser{
 		grammar:    &gr,
 		input:      in,
@@ -87,38 +153,99 @@ func NewLRParser(gr grammar.Grammar, in string) LRParser {
 		state:      normal,
 		production: nil,
 		inputIter:  0,
-		printer:    table,
+		steps:      nil,
 	}
 }
 
+// SetMaxSteps limits the number of steps Parse may take. Zero means no limit.
+func (lrp *LRParser) SetMaxSteps(n int) {
+	lrp.maxSteps = n
+}
+
+// SetMaxDepth limits the size of each of the L1 and L2 stacks. Zero means no
+// limit.
+func (lrp *LRParser) SetMaxDepth(n int) {
+	lrp.maxDepth = n
+}
+
+// SetTimeout limits the time Parse may take. Zero means no limit.
+func (lrp *LRParser) SetTimeout(d time.Duration) {
+	lrp.timeout = d
+}
+
+// SetTrace makes Parse record its steps for Steps. The trace is off by
+// default, since each step copies both stacks into it.
+func (lrp *LRParser) SetTrace(trace bool) {
+	lrp.trace = trace
+}
+
+// Steps returns the steps taken by the last call to Parse, if the trace
+// is on.
+func (lrp *LRParser) Steps() []Step {
+	return lrp.steps
+}
+
+// StepsReport returns the steps taken by the last call to Parse.
+func (lrp *LRParser) StepsReport() report.Table {
+	table := report.Table{
+		Title:  "Steps",
+		Header: []string{"State", "L1", "L2", "Input"},
+	}
+
+	for _, st := range lrp.steps {
+		table.Rows = append(table.Rows, []string{st.State, st.L1, st.L2, st.Input})
+	}
+
+	steps := lrp.steps
+	table.Style = func(row, col int) report.Style {
+		if col != 0 {
+			return report.NoStyle
+		}
+
+		return stateStyles[steps[row].State]
+	}
+
+	return table
+}
+
+var stateStyles = map[string]report.Style{
+	"normal": report.Green,
+	"ret":    report.Red,
+}
+
+// PrintSteps prints the steps taken by the last call to Parse.
+func (lrp *LRParser) PrintSteps() {
+	report.Write(os.Stdout, report.Color, lrp.StepsReport())
+}
+
+// Production returns the rules applied by the last successful call to
+// Parse, which is a leftmost derivation.
+func (lrp *LRParser) Production() []int {
+	return lrp.production
+}
+
 func (lrp *LRParser) expandTree() {
-	symbol := lrp.l2Stack[0].token
-	nToken := lrp.grammar.NTokens[lrp.grammar.FindNToken(symbol)]
+	symbol := lrp.l2Top().symbol
+	nToken := lrp.grammar.NTokens[lrp.grammar.NTokenIndex(symbol)]
 	l1Token := l1StackNode{
-		token:     nToken.NTSymbol,
+		symbol:    symbol,
 		tokenType: grammar.NTerm,
 		altCount:  nToken.AltCount,
 		altNum:    1,
+		rule:      nToken.Alt[0],
 	}
 
 	lrp.pushL1Stack(l1Token)
 
-	numRule := nToken.Alt[l1Token.altNum-1]
-	ruleRSymbol := lrp.grammar.Rules[numRule].RSymbol
-	l2token := l2StackNode{
-		token:     ruleRSymbol,
-		tokenType: lrp.grammar.TokenType(ruleRSymbol),
-	}
-
-	lrp.l2Stack = lrp.l2Stack[1:]
-	lrp.pushL2Stack(l2token)
+	lrp.popL2Stack(1)
+	lrp.pushL2Stack(lrp.grammar.RightIDs(l1Token.rule))
 }
 
 func (lrp *LRParser) pushL2NodeToL1Stack() {
 	lrp.inputIter++
 
 	l1Token := l1StackNode{
-		token:     lrp.l2Stack[0].token,
+		symbol:    lrp.l2Top().symbol,
 		tokenType: grammar.Term,
 		altCount:  0,
 		altNum:    1,
@@ -126,114 +253,111 @@ func (lrp *LRParser) pushL2NodeToL1Stack() {
 
 	lrp.pushL1Stack(l1Token)
 
-	lrp.l2Stack = lrp.l2Stack[1:]
+	lrp.popL2Stack(1)
 }
 
 func (lrp *LRParser) pushL1NodeToL2Stack() {
 	lrp.inputIter--
 
-	l2Token := l2StackNode{
-		token:     lrp.l1Stack[0].token,
-		tokenType: grammar.Term,
-	}
-
-	lrp.pushL2Stack(l2Token)
+	lrp.pushL2Stack([]int{lrp.l1Top().symbol})
 
-	lrp.l1Stack = lrp.l1Stack[1:]
+	lrp.popL1Stack()
 }
 
 func (lrp *LRParser) successfulCompletion() {
 	lrp.state = end
 
+	// the rules from the bottom of L1 up are the leftmost derivation
 	for _, l1Token := range lrp.l1Stack {
 		if l1Token.tokenType == grammar.Term {
 			continue
 		}
 
-		it := lrp.grammar.FindNToken(l1Token.token)
-		r := lrp.grammar.NTokens[it].Alt[l1Token.altNum-1]
-
-		lrp.production = append(lrp.production, r)
-	}
-
-	for i := len(lrp.production)/2 - 1; i >= 0; i-- {
-		j := len(lrp.production) - 1 - i
-		lrp.production[i], lrp.production[j] = lrp.production[j], lrp.production[i]
+		lrp.production = append(lrp.production, l1Token.rule)
 	}
 }
 
 func (lrp *LRParser) testAlternative() {
 	lrp.state = normal
 
-	lrp.l1Stack[0].altNum++
+	top := lrp.l1Top()
+	lrp.popL2Stack(len(lrp.grammar.RightIDs(top.rule)))
 
-	tokenIndex := lrp.grammar.FindNToken(lrp.l1Stack[0].token)
-	ruleNum := lrp.grammar.NTokens[tokenIndex].Alt[lrp.l1Stack[0].altNum-1]
+	top.altNum++
 
-	ruleRSymbol := lrp.grammar.Rules[ruleNum].RSymbol
-	orRule := lrp.grammar.Rules[ruleNum-1].RSymbol
-	lrp.l2Stack = lrp.l2Stack[len(orRule):]
-	lrp.pushL2Stack(
-		l2StackNode{
-			token:     ruleRSymbol,
-			tokenType: -1,
-		},
-	)
+	tokenIndex := lrp.grammar.NTokenIndex(top.symbol)
+	ruleNum := lrp.grammar.NTokens[tokenIndex].Alt[top.altNum-1]
+	top.rule = ruleNum
+
+	lrp.pushL2Stack(lrp.grammar.RightIDs(ruleNum))
 }
 
 func (lrp *LRParser) returnNonTerm() {
-	tokenIndex := lrp.grammar.FindNToken(lrp.l1Stack[0].token)
-	ruleNum := lrp.grammar.NTokens[tokenIndex].Alt[lrp.l1Stack[0].altNum-1]
-	ruleRSymbol := lrp.grammar.Rules[ruleNum].RSymbol
-	ruleLSymbol := lrp.grammar.Rules[ruleNum].LSymbol
-	lrp.l2Stack = lrp.l2Stack[len(ruleRSymbol):]
-	lrp.pushL2Stack(
-		l2StackNode{
-			token:     ruleLSymbol,
-			tokenType: -1,
-		},
-	)
+	ruleNum := lrp.l1Top().rule
+	lrp.popL2Stack(len(lrp.grammar.RightIDs(ruleNum)))
+	lrp.pushL2Stack([]int{lrp.grammar.LeftID(ruleNum)})
 
-	lrp.l1Stack = lrp.l1Stack[1:]
+	lrp.popL1Stack()
 }
 
 func (lrp *LRParser) updateTable() {
+	if !lrp.trace {
+		return
+	}
+
 	var state string
 	switch lrp.state {
 	case normal:
-		state = "\033[32mnormal\033[0m"
+		state = "normal"
 	case ret:
-		state = "\033[31mret\033[0m"
+		state = "ret"
 	case end:
 		state = "end"
 	}
 
-	var l1Stack string
+	// L1 is written from the bottom and L2 from the top
+	var l1Stack strings.Builder
 	for i := range lrp.l1Stack {
-		var index string
-		if lrp.grammar.TokenType(lrp.l1Stack[i].token) == grammar.NTerm {
-			index = getIndex(lrp.l1Stack[i].altNum)
-		} else {
-			index = ""
+		l1Stack.WriteString(lrp.grammar.Symbol(lrp.l1Stack[i].symbol))
+		if lrp.l1Stack[i].tokenType == grammar.NTerm {
+			l1Stack.WriteString(getIndex(lrp.l1Stack[i].altNum))
 		}
-		l1Stack = fmt.Sprintf("%s%s", lrp.l1Stack[i].token+index, l1Stack)
 	}
 
-	var l2Stack string
+	var l2Stack strings.Builder
+	for i := len(lrp.l2Stack) - 1; i >= 0; i-- {
+		l2Stack.WriteString(lrp.grammar.Symbol(lrp.l2Stack[i].symbol))
+	}
 
-	for i := range lrp.l2Stack {
-		l2Stack += lrp.l2Stack[i].token
+	lrp.steps = append(
+		lrp.steps,
+		Step{
+			State: state,
+			L1:    l1Stack.String(),
+			L2:    l2Stack.String(),
+			Input: lrp.input[lrp.inputIter:],
+		},
+	)
+}
+
+func (lrp *LRParser) checkLimits(ctx context.Context, step int) error {
+	if err := ctx.Err(); err != nil {
+		limit := LimitCanceled
+		if errors.Is(err, context.DeadlineExceeded) {
+			limit = LimitTimeout
+		}
+		return &LimitError{Limit: limit, Taken: step, Steps: lrp.steps, Err: err}
 	}
-	// if len(lrp.l2Stack) > 0 {
-	// 	l2Stack = lrp.l2Stack[0].token
-	// } else {
-	// 	l2Stack = "e"
-	// }
 
-	var pointer string
-	pointer = lrp.input[lrp.inputIter:]
+	if lrp.maxSteps > 0 && step >= lrp.maxSteps {
+		return &LimitError{Limit: LimitSteps, Taken: step, Steps: lrp.steps}
+	}
 
-	lrp.printer.AppendBulk([][]string{{state, l1Stack, l2Stack, pointer}})
+	if lrp.maxDepth > 0 && (len(lrp.l1Stack) > lrp.maxDepth || len(lrp.l2Stack) > lrp.maxDepth) {
+		return &LimitError{Limit: LimitDepth, Taken: step, Steps: lrp.steps}
+	}
+
+	return nil
 }
 
 func getIndex(num int) string {
@@ -273,45 +397,71 @@ func getIndex(num int) string {
 }
 
 func (lrp *LRParser) Parse() error {
+	return lrp.ParseContext(context.Background())
+}
+
+// ParseContext is like Parse but stops with a *LimitError when ctx is done
+// or one of the configured limits is hit.
+func (lrp *LRParser) ParseContext(ctx context.Context) error {
 	if len(lrp.input) == 0 {
 		return fmt.Errorf("input srtring is empty")
 	}
 
-	lrp.updateTable()
+	if lrp.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, lrp.timeout)
+		defer cancel()
+	}
+
+	// the IDs of the input symbols, -1 for the symbols not in the grammar
+	lrp.inputIDs = make([]int, len(lrp.input))
+	for i := range lrp.input {
+		lrp.inputIDs[i] = lrp.grammar.ID(lrp.input[i : i+1])
+	}
+
+	lrp.steps = make([]Step, 0)
+
+	for step := 0; ; step++ {
+		// the limits are checked before the state is traced, so the trace
+		// never holds a stack beyond the depth limit
+		if err := lrp.checkLimits(ctx, step); err != nil {
+			return err
+		}
+		lrp.updateTable()
 
-	for {
 		switch lrp.state {
 		case normal:
 			switch {
-			case lrp.l2Stack[0].tokenType == grammar.NTerm:
+			case len(lrp.l2Stack) == 0:
+				if lrp.inputIter == len(lrp.input) {
+					lrp.successfulCompletion()
+				} else {
+					lrp.state = ret
+				}
+				continue
+
+			case lrp.l2Top().tokenType == grammar.NTerm:
 				lrp.expandTree()
-				lrp.updateTable()
 				continue
 
-			case lrp.l2Stack[0].tokenType == grammar.Term && lrp.l2Stack[0].token != string(lrp.input[lrp.inputIter]):
+			case lrp.inputIter == len(lrp.input) || lrp.l2Top().symbol != lrp.inputIDs[lrp.inputIter]:
 				lrp.state = ret
-				lrp.updateTable()
 				continue
 
-			case lrp.l2Stack[0].tokenType == grammar.Term && lrp.l2Stack[0].token == string(lrp.input[lrp.inputIter]):
+			default:
 				lrp.pushL2NodeToL1Stack()
-				lrp.updateTable()
 				if lrp.inputIter == len(lrp.input) {
 					switch len(lrp.l2Stack) {
 					case 0:
 						lrp.successfulCompletion()
-						lrp.updateTable()
 						continue
 					default:
-						lrp.state = ret
-						lrp.updateTable()
 						continue
 					}
 				} else {
 					switch len(lrp.l2Stack) {
 					case 0:
 						lrp.state = ret
-						lrp.updateTable()
 						continue
 					default:
 						continue
@@ -321,28 +471,22 @@ func (lrp *LRParser) Parse() error {
 
 		case ret:
 			switch {
-			case lrp.l1Stack[0].tokenType == grammar.Term:
+			case lrp.l1Top().tokenType == grammar.Term:
 				lrp.pushL1NodeToL2Stack()
-				lrp.updateTable()
 				continue
-			case lrp.l1Stack[0].tokenType == grammar.NTerm && lrp.l1Stack[0].altNum < lrp.l1Stack[0].altCount:
+			case lrp.l1Top().tokenType == grammar.NTerm && lrp.l1Top().altNum < lrp.l1Top().altCount:
 				lrp.testAlternative()
-				lrp.updateTable()
 				continue
-			case lrp.l1Stack[0].tokenType == grammar.NTerm && lrp.l1Stack[0].altNum >= lrp.l1Stack[0].altCount:
-				if lrp.l1Stack[0].token == lrp.grammar.Root && lrp.inputIter == 0 {
+			case lrp.l1Top().tokenType == grammar.NTerm && lrp.l1Top().altNum >= lrp.l1Top().altCount:
+				if len(lrp.l1Stack) == 1 {
 					return fmt.Errorf("the input string does not belong to the grammar")
 				} else {
 					lrp.returnNonTerm()
-					lrp.updateTable()
 					continue
 				}
 			}
 
 		case end:
-			fmt.Println("\033[1mSteps:\033[0m")
-			lrp.printer.Render()
-			fmt.Printf("\033[1mLeft out:\033[0m %d\n", lrp.production)
 			return nil
 		}
 	}
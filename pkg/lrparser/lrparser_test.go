@@ -0,0 +1,329 @@
+package lrparser
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+type testGrammar struct {
+	name     string
+	settings grammar.GrammarSettings
+	accept   []string
+	reject   []string
+}
+
+var testGrammars = []testGrammar{
+	{
+		name: "sum of products",
+		settings: grammar.GrammarSettings{
+			Root:      "B",
+			TSymbols:  []string{"+", "*", "a", "b", "(", ")"},
+			NTSymbols: []string{"B", "T", "M"},
+			Rules: []grammar.Rule{
+				{LSymbol: "B", RSymbol: "T+B"},
+				{LSymbol: "B", RSymbol: "T"},
+				{LSymbol: "T", RSymbol: "M*T"},
+				{LSymbol: "T", RSymbol: "M"},
+				{LSymbol: "M", RSymbol: "a"},
+				{LSymbol: "M", RSymbol: "b"},
+				{LSymbol: "M", RSymbol: "(B)"},
+			},
+		},
+		accept: []string{"a", "b", "a+b", "a*b", "a+b*a", "(a+b)*b", "((a))"},
+		reject: []string{"+", "a+", "ab", "(a", "a)"},
+	},
+	{
+		name: "pairs of c-strings",
+		settings: grammar.GrammarSettings{
+			Root:      "S",
+			TSymbols:  []string{"c", "d"},
+			NTSymbols: []string{"S", "E", "C"},
+			Rules: []grammar.Rule{
+				{LSymbol: "S", RSymbol: "E"},
+				{LSymbol: "E", RSymbol: "CC"},
+				{LSymbol: "C", RSymbol: "cC"},
+				{LSymbol: "C", RSymbol: "d"},
+			},
+		},
+		accept: []string{"dd", "cdd", "dcd", "ccdcccd"},
+		reject: []string{"d", "c", "ddd", "cc"},
+	},
+}
+
+// interleave returns the rules in a random order that keeps the relative
+// order of each nonterminal's alternatives, so the parse must not change.
+func interleave(rules []grammar.Rule, rnd *rand.Rand) []grammar.Rule {
+	queues := make(map[string][]grammar.Rule)
+	var order []string
+	for _, r := range rules {
+		if _, ok := queues[r.LSymbol]; !ok {
+			order = append(order, r.LSymbol)
+		}
+		queues[r.LSymbol] = append(queues[r.LSymbol], r)
+	}
+
+	shuffled := make([]grammar.Rule, 0, len(rules))
+	for len(shuffled) < len(rules) {
+		ls := order[rnd.Intn(len(order))]
+		if len(queues[ls]) == 0 {
+			continue
+		}
+		shuffled = append(shuffled, queues[ls][0])
+		queues[ls] = queues[ls][1:]
+	}
+
+	return shuffled
+}
+
+func parse(t *testing.T, gs grammar.GrammarSettings, in string) ([]string, error) {
+	gr, err := grammar.New(gs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lrp := NewLRParser(*gr, in)
+	lrp.SetMaxSteps(100000)
+	if err := lrp.Parse(); err != nil {
+		return nil, err
+	}
+
+	var derivation []string
+	for _, r := range lrp.production {
+		derivation = append(derivation, gr.Rules[r].LSymbol+"->"+gr.Rules[r].RSymbol)
+	}
+
+	return derivation, nil
+}
+
+// derive applies the rules of a leftmost derivation to the root symbol.
+func derive(root string, derivation []string) string {
+	form := root
+	for _, r := range derivation {
+		sides := strings.SplitN(r, "->", 2)
+		form = strings.Replace(form, sides[0], sides[1], 1)
+	}
+
+	return form
+}
+
+func TestParseShuffledRules(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, tg := range testGrammars {
+		for _, in := range tg.accept {
+			want, err := parse(t, tg.settings, in)
+			if err != nil {
+				t.Fatalf("%s: %q: %v", tg.name, in, err)
+			}
+			if got := derive(tg.settings.Root, want); got != in {
+				t.Fatalf("%s: %q: derivation %v yields %q", tg.name, in, want, got)
+			}
+
+			for i := 0; i < 20; i++ {
+				gs := tg.settings
+				gs.Rules = interleave(tg.settings.Rules, rnd)
+
+				got, err := parse(t, gs, in)
+				if err != nil {
+					t.Errorf("%s: rules %v: %q: %v", tg.name, gs.Rules, in, err)
+					continue
+				}
+				if strings.Join(got, " ") != strings.Join(want, " ") {
+					t.Errorf("%s: rules %v: %q: got %v, want %v", tg.name, gs.Rules, in, got, want)
+				}
+			}
+		}
+
+		for _, in := range tg.reject {
+			for i := 0; i < 20; i++ {
+				gs := tg.settings
+				gs.Rules = interleave(tg.settings.Rules, rnd)
+
+				if got, err := parse(t, gs, in); err == nil {
+					t.Errorf("%s: rules %v: %q: accepted with %v", tg.name, gs.Rules, in, got)
+				}
+			}
+		}
+	}
+}
+
+func TestParseEdgeCases(t *testing.T) {
+	tests := []testGrammar{
+		{
+			// the expansion of an empty alternative leaves the L2 stack
+			// empty before the input is read
+			name: "epsilon rules",
+			settings: grammar.GrammarSettings{
+				Root:      "S",
+				TSymbols:  []string{"a", "b"},
+				NTSymbols: []string{"S"},
+				Rules: []grammar.Rule{
+					{LSymbol: "S", RSymbol: "aSb"},
+					{LSymbol: "S", RSymbol: ""},
+				},
+			},
+			accept: []string{"ab", "aabb", "aaabbb"},
+			reject: []string{"a", "b", "ba", "aab", "abb", "abab"},
+		},
+		{
+			// the input runs out while terminals are still expected, and
+			// a shorter alternative has to be tried after the last shift
+			name: "input runs out",
+			settings: grammar.GrammarSettings{
+				Root:      "S",
+				TSymbols:  []string{"a", "b", "c"},
+				NTSymbols: []string{"S", "A"},
+				Rules: []grammar.Rule{
+					{LSymbol: "S", RSymbol: "Ac"},
+					{LSymbol: "S", RSymbol: "A"},
+					{LSymbol: "A", RSymbol: "abc"},
+					{LSymbol: "A", RSymbol: "ab"},
+				},
+			},
+			accept: []string{"ab", "abc", "abcc"},
+			reject: []string{"a", "abb", "abccc", "c"},
+		},
+	}
+
+	for _, tg := range tests {
+		for _, in := range tg.accept {
+			derivation, err := parse(t, tg.settings, in)
+			if err != nil {
+				t.Errorf("%s: %q: %v", tg.name, in, err)
+				continue
+			}
+			if got := derive(tg.settings.Root, derivation); got != in {
+				t.Errorf("%s: %q: derivation %v yields %q", tg.name, in, derivation, got)
+			}
+		}
+
+		for _, in := range tg.reject {
+			if derivation, err := parse(t, tg.settings, in); err == nil {
+				t.Errorf("%s: %q: accepted with %v", tg.name, in, derivation)
+			}
+		}
+	}
+}
+
+// leftRecursive is a grammar the parser expands without end.
+var leftRecursive = grammar.GrammarSettings{
+	Root:      "E",
+	TSymbols:  []string{"+", "a"},
+	NTSymbols: []string{"E"},
+	Rules: []grammar.Rule{
+		{LSymbol: "E", RSymbol: "E+a"},
+		{LSymbol: "E", RSymbol: "a"},
+	},
+}
+
+func TestParseLimits(t *testing.T) {
+	gr, err := grammar.New(leftRecursive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name  string
+		setup func(lrp *LRParser)
+		ctx   context.Context
+		limit Limit
+		err   error
+	}{
+		{
+			name:  "steps",
+			setup: func(lrp *LRParser) { lrp.SetMaxSteps(50) },
+			ctx:   context.Background(),
+			limit: LimitSteps,
+		},
+		{
+			name:  "depth",
+			setup: func(lrp *LRParser) { lrp.SetMaxDepth(20) },
+			ctx:   context.Background(),
+			limit: LimitDepth,
+		},
+		{
+			name:  "timeout",
+			setup: func(lrp *LRParser) { lrp.SetTimeout(10 * time.Millisecond) },
+			ctx:   context.Background(),
+			limit: LimitTimeout,
+			err:   context.DeadlineExceeded,
+		},
+		{
+			name:  "cancellation",
+			setup: func(lrp *LRParser) {},
+			ctx:   canceled,
+			limit: LimitCanceled,
+			err:   context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		lrp := NewLRParser(*gr, "a+b")
+		lrp.SetTrace(true)
+		tt.setup(&lrp)
+
+		err := lrp.ParseContext(tt.ctx)
+
+		var le *LimitError
+		if !errors.As(err, &le) {
+			t.Errorf("%s: error %v, want a *LimitError", tt.name, err)
+			continue
+		}
+		if le.Limit != tt.limit {
+			t.Errorf("%s: stopped by %v, want %v", tt.name, le.Limit, tt.limit)
+		}
+		// the trace holds the state before each step taken
+		if len(le.Steps) != le.Taken || len(le.Steps) != len(lrp.Steps()) {
+			t.Errorf("%s: %d steps in the error, %d in the trace, %d taken", tt.name, len(le.Steps), len(lrp.Steps()), le.Taken)
+		}
+		if errors.Unwrap(err) != tt.err {
+			t.Errorf("%s: unwraps to %v, want %v", tt.name, errors.Unwrap(err), tt.err)
+		}
+		if want := "parsing stopped by " + tt.limit.String(); !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s: error %q, want it to start with %q", tt.name, err, want)
+		}
+	}
+}
+
+func TestParseLimitsUntraced(t *testing.T) {
+	gr, err := grammar.New(leftRecursive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lrp := NewLRParser(*gr, "a+b")
+	lrp.SetMaxSteps(50)
+
+	var le *LimitError
+	if err := lrp.Parse(); !errors.As(err, &le) || le.Taken != 50 {
+		t.Fatalf("error %v, want a *LimitError after 50 steps", err)
+	}
+	if len(le.Steps) != 0 || len(lrp.Steps()) != 0 {
+		t.Errorf("%d steps traced with the trace off", len(le.Steps))
+	}
+}
+
+func TestParseLimitsNotHit(t *testing.T) {
+	gr, err := grammar.New(testGrammars[0].settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lrp := NewLRParser(*gr, "a+b*a")
+	lrp.SetMaxSteps(10000)
+	lrp.SetMaxDepth(100)
+	lrp.SetTimeout(time.Minute)
+
+	if err := lrp.ParseContext(context.Background()); err != nil {
+		t.Errorf("limits far away: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package parsetree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+// Leftmost returns the sentential forms of a leftmost derivation given by
+// the rules in the order they are applied.
+func Leftmost(gr *grammar.Grammar, rules []int) ([]string, error) {
+	return derive(gr, rules, false)
+}
+
+// Rightmost returns the sentential forms of the rightmost derivation whose
+// reverse are the reductions of an LR parser.
+func Rightmost(gr *grammar.Grammar, reductions []int) ([]string, error) {
+	rules := make([]int, len(reductions))
+	for i := range reductions {
+		rules[len(reductions)-1-i] = reductions[i]
+	}
+
+	return derive(gr, rules, true)
+}
+
+func derive(gr *grammar.Grammar, rules []int, rightmost bool) ([]string, error) {
+	form := gr.Root
+	forms := []string{form}
+
+	for _, r := range rules {
+		pos := -1
+		for i := 0; i < len(form); i++ {
+			j := i
+			if rightmost {
+				j = len(form) - 1 - i
+			}
+
+			if gr.TokenType(form[j:j+1]) == grammar.NTerm {
+				pos = j
+				break
+			}
+		}
+
+		if pos < 0 {
+			return nil, fmt.Errorf("no nonterminal left for rule %d", r)
+		}
+		if r < 0 || r >= len(gr.Rules) || gr.Rules[r].LSymbol != form[pos:pos+1] {
+			return nil, fmt.Errorf("rule %d cannot expand %s", r, form[pos:pos+1])
+		}
+
+		form = form[:pos] + gr.Rules[r].RSymbol + form[pos+1:]
+		forms = append(forms, form)
+	}
+
+	return forms, nil
+}
+
+// Derivation joins the sentential forms with ⇒, writing the empty form
+// as Epsilon.
+func Derivation(forms []string) string {
+	shown := make([]string, len(forms))
+	for i := range forms {
+		shown[i] = forms[i]
+		if shown[i] == "" {
+			shown[i] = grammar.Epsilon
+		}
+	}
+
+	return strings.Join(shown, " ⇒ ")
+}
@@ -0,0 +1,237 @@
+package parsetree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+	"github.com/svkirillov/translator-labs/pkg/report"
+)
+
+// Node is a node of a parse tree. Inner nodes hold a nonterminal and the
+// rule applied to it, leaves hold a terminal or Epsilon.
+type Node struct {
+	Symbol   string
+	Rule     int // -1 for leaves
+	Children []*Node
+}
+
+// FromLeftmost builds the tree of a leftmost derivation given by the rules
+// in the order they are applied, as LRParser and LL1Parser return them.
+func FromLeftmost(gr *grammar.Grammar, rules []int) (*Node, error) {
+	b := builder{grammar: gr, rules: rules}
+
+	root, err := b.build(gr.Root, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.next != len(rules) {
+		return nil, fmt.Errorf("derivation has %d unused rules", len(rules)-b.next)
+	}
+
+	return root, nil
+}
+
+// FromRightmost builds the tree of the reductions of an LR parser, which
+// are a rightmost derivation in reverse, as LR1Parser returns them.
+func FromRightmost(gr *grammar.Grammar, reductions []int) (*Node, error) {
+	rules := make([]int, len(reductions))
+	for i := range reductions {
+		rules[len(reductions)-1-i] = reductions[i]
+	}
+
+	b := builder{grammar: gr, rules: rules}
+
+	root, err := b.build(gr.Root, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.next != len(rules) {
+		return nil, fmt.Errorf("derivation has %d unused rules", len(rules)-b.next)
+	}
+
+	return root, nil
+}
+
+type builder struct {
+	grammar *grammar.Grammar
+	rules   []int
+	next    int
+}
+
+// build expands the symbol with the next rule and then its children, from
+// right to left for a rightmost derivation.
+func (b *builder) build(symbol string, rightmost bool) (*Node, error) {
+	if b.grammar.TokenType(symbol) == grammar.Term {
+		return &Node{Symbol: symbol, Rule: -1}, nil
+	}
+
+	if b.next >= len(b.rules) {
+		return nil, fmt.Errorf("derivation ends before %s is expanded", symbol)
+	}
+
+	r := b.rules[b.next]
+	if r < 0 || r >= len(b.grammar.Rules) || b.grammar.Rules[r].LSymbol != symbol {
+		return nil, fmt.Errorf("rule %d cannot expand %s", r, symbol)
+	}
+	b.next++
+
+	rs := b.grammar.Rules[r].RSymbol
+	node := &Node{Symbol: symbol, Rule: r, Children: make([]*Node, len(rs))}
+
+	if rs == "" {
+		node.Children = []*Node{{Symbol: grammar.Epsilon, Rule: -1}}
+		return node, nil
+	}
+
+	for i := range rs {
+		j := i
+		if rightmost {
+			j = len(rs) - 1 - i
+		}
+
+		child, err := b.build(rs[j:j+1], rightmost)
+		if err != nil {
+			return nil, err
+		}
+		node.Children[j] = child
+	}
+
+	return node, nil
+}
+
+// Yield returns the terminals of the leaves of the tree.
+func (n *Node) Yield() string {
+	if n.Rule < 0 {
+		if n.Symbol == grammar.Epsilon {
+			return ""
+		}
+		return n.Symbol
+	}
+
+	var yield strings.Builder
+	for _, c := range n.Children {
+		yield.WriteString(c.Yield())
+	}
+
+	return yield.String()
+}
+
+// String returns the tree drawn with indentation and box-drawing lines.
+func (n *Node) String() string {
+	var b strings.Builder
+
+	b.WriteString(n.Symbol)
+	b.WriteString("\n")
+	n.writeASCII(&b, "")
+
+	return b.String()
+}
+
+func (n *Node) writeASCII(b *strings.Builder, prefix string) {
+	for i, c := range n.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		b.WriteString(prefix + branch + c.Symbol + "\n")
+		c.writeASCII(b, prefix+indent)
+	}
+}
+
+// WriteDOT writes the tree in the Graphviz DOT language.
+func (n *Node) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph tree {")
+	fmt.Fprintln(bw, "\tnode [shape=plaintext];")
+
+	id := 0
+	var walk func(n *Node) int
+	walk = func(n *Node) int {
+		me := id
+		id++
+
+		label := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(n.Symbol)
+		if n.Rule < 0 {
+			fmt.Fprintf(bw, "\t%d [label=\"%s\", fontcolor=\"blue\"];\n", me, label)
+		} else {
+			fmt.Fprintf(bw, "\t%d [label=\"%s\"];\n", me, label)
+		}
+
+		for _, c := range n.Children {
+			fmt.Fprintf(bw, "\t%d -> %d;\n", me, walk(c))
+		}
+
+		return me
+	}
+	walk(n)
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// latexSymbol escapes the symbol for qtree and forest, which need braces
+// around brackets and other punctuation.
+func latexSymbol(s string) string {
+	escaped := report.EscapeLaTeX(s)
+	if escaped != s || strings.ContainsAny(s, "[](),.=+-*/<>!?:;|") {
+		return "{" + escaped + "}"
+	}
+
+	return s
+}
+
+// QTree returns the tree as a \Tree command of the LaTeX qtree package.
+func (n *Node) QTree() string {
+	var b strings.Builder
+
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if len(n.Children) == 0 {
+			b.WriteString(latexSymbol(n.Symbol))
+			return
+		}
+
+		b.WriteString("[." + latexSymbol(n.Symbol))
+		for _, c := range n.Children {
+			b.WriteString(" ")
+			walk(c)
+		}
+		b.WriteString(" ]")
+	}
+
+	b.WriteString("\\Tree ")
+	walk(n)
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// Forest returns the tree as a forest environment of the LaTeX forest
+// package.
+func (n *Node) Forest() string {
+	var b strings.Builder
+
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		b.WriteString("[" + latexSymbol(n.Symbol))
+		for _, c := range n.Children {
+			b.WriteString(" ")
+			walk(c)
+		}
+		b.WriteString("]")
+	}
+
+	b.WriteString("\\begin{forest}\n")
+	walk(n)
+	b.WriteString("\n\\end{forest}\n")
+
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package parsetree
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/svkirillov/translator-labs/pkg/grammar"
+)
+
+// sum is the grammar of the tests, with the rules numbered
+//
+//	0 S -> E
+//	1 E -> E+T
+//	2 E -> T
+//	3 T -> a
+//	4 T -> (E)
+const sum = `
+S -> E
+E -> E+T | T
+T -> a | (E)
+`
+
+// the derivations of a+a
+var (
+	leftmost    = []int{0, 1, 2, 3, 3}
+	reductions  = []int{3, 2, 3, 1, 0}
+	sumTreeText = `S
+└── E
+    ├── E
+    │   └── T
+    │       └── a
+    ├── +
+    └── T
+        └── a
+`
+)
+
+func read(t *testing.T, text string) *grammar.Grammar {
+	t.Helper()
+
+	gr, err := grammar.Read(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gr
+}
+
+func TestDerivation(t *testing.T) {
+	gr := read(t, sum)
+
+	forms, err := Leftmost(gr, leftmost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Derivation(forms), "S ⇒ E ⇒ E+T ⇒ T+T ⇒ a+T ⇒ a+a"; got != want {
+		t.Errorf("leftmost: got %s, want %s", got, want)
+	}
+
+	forms, err = Rightmost(gr, reductions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Derivation(forms), "S ⇒ E ⇒ E+T ⇒ E+a ⇒ T+a ⇒ a+a"; got != want {
+		t.Errorf("rightmost: got %s, want %s", got, want)
+	}
+
+	if got, want := Derivation([]string{"S", "aS", "a"}), "S ⇒ aS ⇒ a"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := Derivation([]string{"S", ""}), "S ⇒ "+grammar.Epsilon; got != want {
+		t.Errorf("empty form: got %s, want %s", got, want)
+	}
+}
+
+func TestDerivationErrors(t *testing.T) {
+	gr := read(t, sum)
+
+	tests := []struct {
+		rules []int
+		want  string
+	}{
+		{[]int{0, 3}, "rule 3 cannot expand E"},
+		{[]int{0, 2, 3, 3}, "no nonterminal left for rule 3"},
+		{[]int{7}, "rule 7 cannot expand S"},
+		{[]int{-1}, "rule -1 cannot expand S"},
+	}
+
+	for _, tt := range tests {
+		if _, err := Leftmost(gr, tt.rules); err == nil || err.Error() != tt.want {
+			t.Errorf("Leftmost(%v) = %v, want %s", tt.rules, err, tt.want)
+		}
+	}
+}
+
+func TestFromDerivations(t *testing.T) {
+	gr := read(t, sum)
+
+	left, err := FromLeftmost(gr, leftmost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := FromRightmost(gr, reductions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tree := range map[string]*Node{"leftmost": left, "rightmost": right} {
+		if got := tree.String(); got != sumTreeText {
+			t.Errorf("%s: got\n%s\nwant\n%s", name, got, sumTreeText)
+		}
+		if got := tree.Yield(); got != "a+a" {
+			t.Errorf("%s: yield %q, want a+a", name, got)
+		}
+	}
+}
+
+func TestFromDerivationsErrors(t *testing.T) {
+	gr := read(t, sum)
+
+	tests := []struct {
+		name  string
+		build func(*grammar.Grammar, []int) (*Node, error)
+		rules []int
+		want  string
+	}{
+		{"leftmost", FromLeftmost, []int{0, 2}, "derivation ends before T is expanded"},
+		{"leftmost", FromLeftmost, []int{0, 2, 3, 3}, "derivation has 1 unused rules"},
+		{"leftmost", FromLeftmost, []int{0, 1, 3}, "rule 3 cannot expand E"},
+		{"leftmost", FromLeftmost, []int{9}, "rule 9 cannot expand S"},
+		// the reductions end with the rule of the root
+		{"rightmost", FromRightmost, []int{3, 2}, "rule 2 cannot expand S"},
+		{"rightmost", FromRightmost, []int{2, 0}, "derivation ends before T is expanded"},
+		{"rightmost", FromRightmost, []int{3, 3, 2, 0}, "derivation has 1 unused rules"},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.build(gr, tt.rules); err == nil || err.Error() != tt.want {
+			t.Errorf("%s %v: got %v, want %s", tt.name, tt.rules, err, tt.want)
+		}
+	}
+}
+
+func TestEpsilonLeaf(t *testing.T) {
+	gr := read(t, `S -> aS | ε`)
+
+	tree, err := FromLeftmost(gr, []int{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "S\n├── a\n└── S\n    └── " + grammar.Epsilon + "\n"
+	if got := tree.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := tree.Yield(); got != "a" {
+		t.Errorf("yield %q, want a", got)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	gr := read(t, sum)
+
+	// (a) has brackets, which qtree and forest need in braces
+	tree, err := FromLeftmost(gr, []int{0, 2, 4, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dot strings.Builder
+	if err := tree.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+
+	wantDOT := `digraph tree {
+	node [shape=plaintext];
+	0 [label="S"];
+	1 [label="E"];
+	2 [label="T"];
+	3 [label="(", fontcolor="blue"];
+	2 -> 3;
+	4 [label="E"];
+	5 [label="T"];
+	6 [label="a", fontcolor="blue"];
+	5 -> 6;
+	4 -> 5;
+	2 -> 4;
+	7 [label=")", fontcolor="blue"];
+	2 -> 7;
+	1 -> 2;
+	0 -> 1;
+}
+`
+	if got := dot.String(); got != wantDOT {
+		t.Errorf("WriteDOT: got\n%s\nwant\n%s", got, wantDOT)
+	}
+
+	if got, want := tree.QTree(), "\\Tree [.S [.E [.T {(} [.E [.T a ] ] {)} ] ] ]\n"; got != want {
+		t.Errorf("QTree: got %q, want %q", got, want)
+	}
+
+	wantForest := "\\begin{forest}\n[S [E [T [{(}] [E [T [a]]] [{)}]]]]\n\\end{forest}\n"
+	if got := tree.Forest(); got != wantForest {
+		t.Errorf("Forest: got %q, want %q", got, wantForest)
+	}
+}
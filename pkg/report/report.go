@@ -0,0 +1,380 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Format is an output format of tables.
+type Format int
+
+const (
+	Color Format = iota
+	Text
+	JSON
+	CSV
+	Markdown
+	LaTeX
+)
+
+var formatNames = []string{"color", "text", "json", "csv", "markdown", "latex"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return "unknown format"
+	}
+
+	return formatNames[f]
+}
+
+// Set parses the name of a format, so a Format can be used as a flag.
+func (f *Format) Set(s string) error {
+	for i, name := range formatNames {
+		if s == name {
+			*f = Format(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown format %q, expected one of %s", s, strings.Join(formatNames, ", "))
+}
+
+// Style is the ANSI graphic rendition of a cell in the Color format.
+type Style string
+
+const (
+	NoStyle Style = ""
+	Bold    Style = "1"
+	Red     Style = "31"
+	Green   Style = "32"
+
+	BoldGreen  Style = "1;32"
+	BoldYellow Style = "1;33"
+	BoldBlue   Style = "1;34"
+)
+
+// Table is a titled table of strings. A table without a header is a list
+// and the text formats print it on the title line. Cells may contain ANSI
+// colour escapes, which only the Color format keeps.
+type Table struct {
+	Title  string     `json:"title"`
+	Header []string   `json:"header,omitempty"`
+	Rows   [][]string `json:"rows"`
+
+	// Bordered tables are printed with borders and row lines by the text
+	// formats.
+	Bordered bool `json:"-"`
+
+	// Style returns the style of the cell in a row and a column for the
+	// Color format. A nil Style leaves the cells as they are.
+	Style func(row, col int) Style `json:"-"`
+}
+
+var escapes = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// Plain returns s without colour escapes.
+func Plain(s string) string {
+	return escapes.ReplaceAllString(s, "")
+}
+
+func (t Table) plain() Table {
+	p := Table{
+		Title:    t.Title,
+		Rows:     make([][]string, len(t.Rows)),
+		Bordered: t.Bordered,
+	}
+
+	if t.Header != nil {
+		p.Header = make([]string, len(t.Header))
+		for i := range t.Header {
+			p.Header[i] = Plain(t.Header[i])
+		}
+	}
+
+	for i := range t.Rows {
+		p.Rows[i] = make([]string, len(t.Rows[i]))
+		for j := range t.Rows[i] {
+			p.Rows[i][j] = Plain(t.Rows[i][j])
+		}
+	}
+
+	return p
+}
+
+func (t Table) styled() Table {
+	s := t
+	s.Rows = make([][]string, len(t.Rows))
+
+	for i := range t.Rows {
+		s.Rows[i] = make([]string, len(t.Rows[i]))
+		for j, c := range t.Rows[i] {
+			if style := t.Style(i, j); style != NoStyle && c != "" {
+				c = fmt.Sprintf("\033[%sm%s\033[0m", style, c)
+			}
+			s.Rows[i][j] = c
+		}
+	}
+
+	return s
+}
+
+// Write writes the tables in the format.
+func Write(w io.Writer, f Format, tables ...Table) error {
+	tables = append([]Table{}, tables...)
+	for i := range tables {
+		if f != Color {
+			tables[i] = tables[i].plain()
+		} else if tables[i].Style != nil {
+			tables[i] = tables[i].styled()
+		}
+	}
+
+	switch f {
+	case Color, Text:
+		return writeText(w, f, tables)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(tables)
+	case CSV:
+		return writeCSV(w, tables)
+	case Markdown:
+		return writeMarkdown(w, tables)
+	case LaTeX:
+		return writeLaTeX(w, tables)
+	default:
+		return fmt.Errorf("unknown format %d", f)
+	}
+}
+
+func writeText(w io.Writer, f Format, tables []Table) error {
+	for _, t := range tables {
+		title := t.Title
+		if title != "" {
+			title += ":"
+			if f == Color {
+				title = fmt.Sprintf("\033[%sm%s\033[0m", Bold, title)
+			}
+		}
+
+		if t.Header == nil {
+			var cells []string
+			for _, row := range t.Rows {
+				cells = append(cells, row...)
+			}
+
+			if _, err := fmt.Fprintln(w, strings.TrimSpace(title+" "+strings.Join(cells, " "))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if t.Title != "" {
+			if _, err := fmt.Fprintln(w, title); err != nil {
+				return err
+			}
+		}
+
+		table := tablewriter.NewWriter(w)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAutoWrapText(false)
+
+		if t.Bordered {
+			// the header is the first row so symbols keep their case
+			table.SetRowLine(true)
+			table.Append(t.Header)
+		} else {
+			table.SetBorder(false)
+			table.SetHeader(t.Header)
+		}
+
+		table.AppendBulk(t.Rows)
+		table.Render()
+	}
+
+	return nil
+}
+
+func writeCSV(w io.Writer, tables []Table) error {
+	cw := csv.NewWriter(w)
+
+	for i, t := range tables {
+		if i > 0 {
+			if err := cw.Write(nil); err != nil {
+				return err
+			}
+		}
+
+		if len(tables) > 1 {
+			if err := cw.Write([]string{t.Title}); err != nil {
+				return err
+			}
+		}
+
+		if t.Header != nil {
+			if err := cw.Write(t.Header); err != nil {
+				return err
+			}
+		}
+
+		if err := cw.WriteAll(t.Rows); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`")
+
+func writeMarkdown(w io.Writer, tables []Table) error {
+	var b strings.Builder
+
+	for i, t := range tables {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		if t.Header == nil {
+			var cells []string
+			for _, row := range t.Rows {
+				for _, c := range row {
+					cells = append(cells, markdownCode(c))
+				}
+			}
+			fmt.Fprintf(&b, "**%s:** %s\n", t.Title, strings.Join(cells, " "))
+			continue
+		}
+
+		if t.Title != "" {
+			fmt.Fprintf(&b, "**%s**\n\n", t.Title)
+		}
+
+		writeMarkdownRow(&b, t.Header)
+		b.WriteString("|")
+		for range t.Header {
+			b.WriteString(" --- |")
+		}
+		b.WriteString("\n")
+
+		for _, row := range t.Rows {
+			writeMarkdownRow(&b, row)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCode returns s as a code span, with a fence longer than the
+// backtick runs of s and spaces around s if it starts or ends with one.
+func markdownCode(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if longest == 0 {
+		return "`" + s + "`"
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+
+	return fence + s + fence
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, c := range cells {
+		fmt.Fprintf(b, " %s |", markdownEscaper.Replace(c))
+	}
+	b.WriteString("\n")
+}
+
+var latexEscaper = strings.NewReplacer(
+	"\\", "\\textbackslash{}",
+	"&", "\\&",
+	"%", "\\%",
+	"$", "\\$",
+	"#", "\\#",
+	"_", "\\_",
+	"{", "\\{",
+	"}", "\\}",
+	"~", "\\textasciitilde{}",
+	"^", "\\textasciicircum{}",
+	"ε", "$\\varepsilon$",
+	"⊣", "$\\dashv$",
+	"⇒", "$\\Rightarrow$",
+	"\u2714", "\\checkmark{}",
+	"₀", "$_0$", "₁", "$_1$", "₂", "$_2$", "₃", "$_3$", "₄", "$_4$",
+	"₅", "$_5$", "₆", "$_6$", "₇", "$_7$", "₈", "$_8$", "₉", "$_9$",
+)
+
+// EscapeLaTeX escapes the characters of s that are special in LaTeX.
+func EscapeLaTeX(s string) string {
+	return latexEscaper.Replace(s)
+}
+
+func writeLaTeX(w io.Writer, tables []Table) error {
+	var b strings.Builder
+
+	for i, t := range tables {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		if t.Header == nil {
+			var cells []string
+			for _, row := range t.Rows {
+				for _, c := range row {
+					cells = append(cells, "\\texttt{"+EscapeLaTeX(c)+"}")
+				}
+			}
+			fmt.Fprintf(&b, "\\textbf{%s:} %s\n", EscapeLaTeX(t.Title), strings.Join(cells, " "))
+			continue
+		}
+
+		if t.Title != "" {
+			fmt.Fprintf(&b, "\\textbf{%s}\n\n", EscapeLaTeX(t.Title))
+		}
+
+		fmt.Fprintf(&b, "\\begin{tabular}{|%s}\n\\hline\n", strings.Repeat("l|", len(t.Header)))
+		writeLaTeXRow(&b, t.Header)
+		b.WriteString("\\hline\n")
+		for _, row := range t.Rows {
+			writeLaTeXRow(&b, row)
+		}
+		b.WriteString("\\hline\n\\end{tabular}\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeLaTeXRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i := range cells {
+		escaped[i] = EscapeLaTeX(cells[i])
+	}
+
+	fmt.Fprintf(b, "%s \\\\\n", strings.Join(escaped, " & "))
+}
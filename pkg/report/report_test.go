@@ -0,0 +1,224 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+var tables = []Table{
+	{
+		Title:  "Rules",
+		Header: []string{"rule", "body"},
+		Rows: [][]string{
+			{"S", "a|b"},
+			{"T", "\"x\", y"},
+		},
+	},
+	{
+		Title: "Terminals",
+		Rows:  [][]string{{"a", "\033[31mb\033[0m"}},
+	},
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{JSON, `[
+  {
+    "title": "Rules",
+    "header": [
+      "rule",
+      "body"
+    ],
+    "rows": [
+      [
+        "S",
+        "a|b"
+      ],
+      [
+        "T",
+        "\"x\", y"
+      ]
+    ]
+  },
+  {
+    "title": "Terminals",
+    "rows": [
+      [
+        "a",
+        "b"
+      ]
+    ]
+  }
+]
+`},
+		{CSV, `Rules
+rule,body
+S,a|b
+T,"""x"", y"
+
+Terminals
+a,b
+`},
+		{Markdown, "**Rules**\n\n" +
+			"| rule | body |\n" +
+			"| --- | --- |\n" +
+			"| S | a\\|b |\n" +
+			"| T | \"x\", y |\n" +
+			"\n" +
+			"**Terminals:** `a` `b`\n"},
+		{LaTeX, "\\textbf{Rules}\n\n" +
+			"\\begin{tabular}{|l|l|}\n" +
+			"\\hline\n" +
+			"rule & body \\\\\n" +
+			"\\hline\n" +
+			"S & a|b \\\\\n" +
+			"T & \"x\", y \\\\\n" +
+			"\\hline\n" +
+			"\\end{tabular}\n" +
+			"\n" +
+			"\\textbf{Terminals:} \\texttt{a} \\texttt{b}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			var b strings.Builder
+			if err := Write(&b, tt.format, tables...); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := b.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	for _, f := range []Format{Color, Text} {
+		var b strings.Builder
+		if err := Write(&b, f, tables...); err != nil {
+			t.Fatal(err)
+		}
+
+		got := b.String()
+		if !strings.Contains(got, "Rules:") || !strings.Contains(got, "a|b") {
+			t.Errorf("%s: the rules table is missing in\n%s", f, got)
+		}
+
+		colored := strings.Contains(got, "\033[31mb")
+		if colored != (f == Color) {
+			t.Errorf("%s: colour escapes kept = %v in\n%s", f, colored, got)
+		}
+	}
+}
+
+func TestWriteStyle(t *testing.T) {
+	table := Table{
+		Header: []string{"state", "action"},
+		Rows:   [][]string{{"0", "s1"}, {"1", ""}},
+		Style: func(row, col int) Style {
+			if col == 1 {
+				return BoldYellow
+			}
+			return NoStyle
+		},
+	}
+
+	var color, text strings.Builder
+	if err := Write(&color, Color, table); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&text, Text, table); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(color.String(), "\033[1;33ms1\033[0m") {
+		t.Errorf("color: s1 is not styled in\n%s", color.String())
+	}
+	if strings.Count(color.String(), "\033[") != 2 {
+		t.Errorf("color: want the escapes of s1 only in\n%s", color.String())
+	}
+	if strings.Contains(text.String(), "\033[") {
+		t.Errorf("text: escapes in\n%s", text.String())
+	}
+	if table.Rows[0][1] != "s1" {
+		t.Errorf("the cells of the table are changed to %q", table.Rows[0][1])
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"a", "`a`"},
+		{"", "``"},
+		{"a`b", "``a`b``"},
+		{"a``b", "```a``b```"},
+		{"`", "`` ` ``"},
+		{"a`", "`` a` ``"},
+	}
+
+	for _, tt := range tests {
+		if got := markdownCode(tt.in); got != tt.want {
+			t.Errorf("markdownCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteNoTables(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b.String(); got != "[]\n" {
+		t.Errorf("got %q, want an empty array", got)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, Format(len(formatNames)), tables...); err == nil {
+		t.Error("no error for an unknown format")
+	}
+}
+
+func TestFormatSet(t *testing.T) {
+	for i, name := range formatNames {
+		var f Format
+		if err := f.Set(name); err != nil || f != Format(i) {
+			t.Errorf("Set(%q) = %v, %v", name, f, err)
+		}
+		if f.String() != name {
+			t.Errorf("Format(%d).String() = %q, want %q", i, f.String(), name)
+		}
+	}
+
+	var f Format
+	if err := f.Set("html"); err == nil {
+		t.Error("no error for format html")
+	}
+}
+
+func TestEscapeLaTeX(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"a", "a"},
+		{`a\b`, `a\textbackslash{}b`},
+		{"&%$#_{}", `\&\%\$\#\_\{\}`},
+		{"~^", `\textasciitilde{}\textasciicircum{}`},
+		{"S ⇒ ε⊣", `S $\Rightarrow$ $\varepsilon$$\dashv$`},
+		{"I₁₂", "I$_1$$_2$"},
+		{"\u2714", `\checkmark{}`},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeLaTeX(tt.in); got != tt.want {
+			t.Errorf("EscapeLaTeX(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}